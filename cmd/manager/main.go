@@ -14,6 +14,7 @@ import (
 	"github.com/tektoncd/operator/pkg/apis"
 	"github.com/tektoncd/operator/pkg/controller"
 	operator "github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/pkg/webhook"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
@@ -97,6 +98,8 @@ func main() {
 	mgr, err := manager.New(cfg, manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Port:               operator.WebhookPort,
+		CertDir:            operator.WebhookCertDir,
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -117,6 +120,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup all Webhooks
+	if err := webhook.AddToManager(mgr); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	if err = serveCRMetrics(cfg); err != nil {
 		log.Info("Could not generate and serve custom resource metrics", "error", err.Error())
 	}
@@ -40,6 +40,7 @@ deploy-crd:
 ## Deploy Operator
 deploy-operator: deploy-crd
 	$(Q)oc create -f deploy/operator.yaml
+	$(Q)oc apply -f deploy/webhook.yaml
 
 .PHONY: deploy-clean
 ## Deploy a CR as test
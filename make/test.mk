@@ -211,3 +211,20 @@ test-upgrade-ci: ./vendor
 test-unit:
 	@echo "Running unit tests..."
 	@go test -failfast -v ./pkg/...
+
+#-------------------------------------------------------------------------------
+# integration tests against a local kube-apiserver and etcd
+#-------------------------------------------------------------------------------
+
+KUBEBUILDER_VERSION ?= 2.3.1
+KUBEBUILDER_ASSETS ?= $(shell pwd)/out/kubebuilder/bin
+
+./out/kubebuilder/bin:
+	$(Q)mkdir -p ./out/kubebuilder
+	$(Q)curl -sSL https://github.com/kubernetes-sigs/kubebuilder/releases/download/v$(KUBEBUILDER_VERSION)/kubebuilder_$(KUBEBUILDER_VERSION)_$(shell go env GOOS)_$(shell go env GOARCH).tar.gz \
+		| tar -xz --strip-components=1 -C ./out/kubebuilder
+
+.PHONY: test-integration
+## Runs the controllers against a local kube-apiserver and etcd, downloaded to ./out/kubebuilder
+test-integration: ./vendor ./out/kubebuilder/bin
+	$(Q)KUBEBUILDER_ASSETS=$(KUBEBUILDER_ASSETS) go test -tags integration ${V_FLAG} ./pkg/controller/... ./test/upgrade/... -failfast -timeout 30m
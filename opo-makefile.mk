@@ -3,7 +3,7 @@
 ##########------------------------------------------------------------##########
 
 STABLE_RELEASE_URL := 'https://raw.githubusercontent.com/openshift/tektoncd-pipeline/release-v${PIPELINE_VERSION}/openshift/release/tektoncd-pipeline-v${PIPELINE_VERSION}.yaml'
-PIPELINE_PATH=deploy/resources/v${PIPELINE_VERSION}/pipelines
+PIPELINE_PATH=deploy/resources/pipelines/v${PIPELINE_VERSION}
 .PHONY: opo-payload-pipeline
 opo-payload-pipeline:
 ifndef PIPELINE_VERSION
@@ -16,7 +16,7 @@ endif
 	go fmt pkg/flag/flag.go
 
 TRIGGERS_STABLE_RELEASE_URL='https://raw.githubusercontent.com/openshift/tektoncd-triggers/release-v${TRIGGERS_VERSION}/openshift/release/tektoncd-triggers-v${TRIGGERS_VERSION}.yaml'
-TRIGGERS_PATH='deploy/resources/v${PIPELINE_VERSION}/addons/triggers'
+TRIGGERS_PATH='deploy/resources/triggers/v${TRIGGERS_VERSION}'
 .PHONY: opo-payload-triggers
 opo-payload-triggers:
 ifndef PIPELINE_VERSION
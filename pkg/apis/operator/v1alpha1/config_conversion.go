@@ -0,0 +1,5 @@
+package v1alpha1
+
+// Hub marks v1alpha1 as the version the other versions of Config are
+// converted through. It is the storage version, read by the controllers
+func (*Config) Hub() {}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -9,6 +10,181 @@ import (
 type ConfigSpec struct {
 	// namespace where OpenShift pipelines will be installed
 	TargetNamespace string `json:"targetNamespace"`
+
+	// ManagementState is Managed, Unmanaged to stop the operator from
+	// changing the components, or Removed to uninstall them. Defaults to
+	// Managed
+	// +kubebuilder:validation:Enum=Managed;Unmanaged;Removed
+	ManagementState ManagementState `json:"managementState,omitempty"`
+
+	// MaintenanceWindow restricts the upgrades to the releases of a new
+	// operator version to a recurring window. They are applied right away
+	// when it is not set
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// Pipeline configures the Tekton Pipelines component
+	Pipeline ComponentSpec `json:"pipeline,omitempty"`
+
+	// Triggers configures the Tekton Triggers component
+	Triggers ComponentSpec `json:"triggers,omitempty"`
+
+	// HighAvailability runs several replicas of the controllers and webhooks
+	// when set
+	HighAvailability *HighAvailability `json:"highAvailability,omitempty"`
+
+	// Proxy is used by the controllers when the cluster has no proxy
+	// configuration
+	Proxy *ProxySpec `json:"proxy,omitempty"`
+
+	// CLIDownloads serves the tkn archives linked from the console from the
+	// target namespace when set, for clusters without access to the mirror
+	CLIDownloads *CLIDownloadsSpec `json:"cliDownloads,omitempty"`
+
+	// Overlays patch the shipped manifests after all the other changes of
+	// the operator, in order. Settings without a dedicated field can be
+	// changed this way
+	Overlays []Overlay `json:"overlays,omitempty"`
+}
+
+// ManagementState tells whether the operator manages the installed components
+type ManagementState string
+
+const (
+	// Managed components are installed, upgraded and repaired by the operator
+	Managed ManagementState = "Managed"
+
+	// Unmanaged components are left as they are, e.g. while they are edited
+	// by hand, and are not upgraded
+	Unmanaged ManagementState = "Unmanaged"
+
+	// Removed components are uninstalled, the Config is kept
+	Removed ManagementState = "Removed"
+)
+
+// OverlayType is the kind of patch of an overlay
+type OverlayType string
+
+const (
+	// JSONPatchOverlay is a JSON patch, a list of operations (RFC 6902)
+	JSONPatchOverlay OverlayType = "json"
+
+	// StrategicMergeOverlay is a strategic merge patch; it is applied as a
+	// JSON merge patch (RFC 7386) to the kinds without a Go type, like the
+	// Tekton resources
+	StrategicMergeOverlay OverlayType = "strategic"
+)
+
+// Overlay patches an object of the shipped manifests
+// +k8s:openapi-gen=true
+type Overlay struct {
+	// Target is the object patched
+	Target OverlayTarget `json:"target"`
+
+	// Type of the patch, json or strategic. Defaults to strategic
+	// +kubebuilder:validation:Enum=json;strategic
+	Type OverlayType `json:"type,omitempty"`
+
+	// Patch is the JSON patch or the strategic merge patch, in YAML or JSON
+	Patch string `json:"patch"`
+}
+
+// OverlayTarget selects an object of the shipped manifests, once transformed
+// for the target namespace
+// +k8s:openapi-gen=true
+type OverlayTarget struct {
+	// APIVersion of the object, any version of the kind when empty
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the object
+	Kind string `json:"kind"`
+
+	// Namespace of the object, any namespace when empty
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object
+	Name string `json:"name"`
+}
+
+// CLIDownloadsSpec defines the server of the tkn archives
+// +k8s:openapi-gen=true
+type CLIDownloadsSpec struct {
+	// Image serving the tkn archives over HTTP on port 8080, laid out as
+	// <version>/tkn-<os>-<arch>-<version>.<ext>. Defaults to the image
+	// shipped with the operator
+	Image string `json:"image,omitempty"`
+}
+
+// ProxySpec defines the proxy used by the controllers
+// +k8s:openapi-gen=true
+type ProxySpec struct {
+	// HTTPProxy is the URL of the proxy for HTTP requests
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the URL of the proxy for HTTPS requests
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is a comma-separated list of hosts and CIDRs the proxy is not used for
+	NoProxy string `json:"noProxy,omitempty"`
+
+	// TrustedCA is a PEM encoded bundle of additional CAs trusted by the controllers
+	TrustedCA string `json:"trustedCA,omitempty"`
+}
+
+// HighAvailability defines the replicas of the controllers and webhooks
+// +k8s:openapi-gen=true
+type HighAvailability struct {
+	// Replicas of each controller and webhook, defaults to 2
+	// +kubebuilder:validation:Minimum=2
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period in which upgrades are applied
+// +k8s:openapi-gen=true
+type MaintenanceWindow struct {
+	// Schedule is the start of the window as a cron expression of the
+	// minute, hour, day of month, month and day of week in UTC, e.g.
+	// "0 22 * * 1-5"
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open after each start, e.g. 2h
+	Duration metav1.Duration `json:"duration"`
+}
+
+// ComponentSpec defines the desired state of a Tekton component
+// +k8s:openapi-gen=true
+type ComponentSpec struct {
+	// Version is the release to install, one of the releases bundled with
+	// the operator. The newest bundled release is installed when empty
+	Version string `json:"version,omitempty"`
+
+	// Deployments customizes the Deployments of the component, keyed by
+	// Deployment name
+	Deployments map[string]DeploymentOverride `json:"deployments,omitempty"`
+}
+
+// DeploymentOverride defines the settings applied on top of a shipped Deployment
+// +k8s:openapi-gen=true
+type DeploymentOverride struct {
+	// Replicas is the number of desired pods
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources are the compute resource requirements of every container
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector must match the labels of a node for the pods to be scheduled on it
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the pods
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity contains the scheduling constraints of the pods
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName is the priority class of the pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Env is set on every container, replacing variables of the same name
+	Env []corev1.EnvVar `json:"env,omitempty"`
 }
 
 // ConfigStatus defines the observed state of Config
@@ -21,6 +197,49 @@ type ConfigStatus struct {
 
 	// installation status sorted in reverse chronological order
 	Conditions []ConfigCondition `json:"conditions,omitempty"`
+
+	// LastReconcileRequest is the value of the reconcile-request annotation
+	// that was last handled by re-applying all the components
+	LastReconcileRequest string `json:"lastReconcileRequest,omitempty"`
+
+	// DesiredPipelineVersion is the pipeline release selected by the spec;
+	// the installed release is reported by the conditions
+	DesiredPipelineVersion string `json:"desiredPipelineVersion,omitempty"`
+
+	// DesiredTriggersVersion is the triggers release selected by the spec;
+	// the installed release is reported by the conditions
+	DesiredTriggersVersion string `json:"desiredTriggersVersion,omitempty"`
+
+	// ProxyHash identifies the proxy settings and trusted CA bundle that were
+	// last applied to the controllers
+	ProxyHash string `json:"proxyHash,omitempty"`
+
+	// Inventory summarises the objects last applied for each component; the
+	// objects are listed in the inventory ConfigMap of the Config
+	Inventory []ComponentInventory `json:"inventory,omitempty"`
+
+	// UnmatchedOverlays lists the targets of the overlays that are not part
+	// of the installed manifests, e.g. once a release drops them
+	UnmatchedOverlays []string `json:"unmatchedOverlays,omitempty"`
+
+	// ManagementState is the management state the operator last acted on
+	ManagementState ManagementState `json:"managementState,omitempty"`
+}
+
+// ComponentInventory summarises the objects last applied for a component
+// +k8s:openapi-gen=true
+type ComponentInventory struct {
+	// Component is the name of the component, e.g. pipeline or addons
+	Component string `json:"component"`
+
+	// Count is the number of objects applied for the component
+	Count int32 `json:"count"`
+
+	// Digest identifies the objects and their applied content
+	Digest string `json:"digest"`
+
+	// LastApplied is the time at which the component was last applied
+	LastApplied metav1.Time `json:"lastApplied,omitempty"`
 }
 
 // ConfigCondition defines the observed state of installation at a point in time
@@ -44,6 +263,15 @@ type ConfigCondition struct {
 
 	// The version of OpenShift triggers
 	TriggersVersion string `json:"triggersVersion,omitempty"`
+
+	// Attempts is the number of consecutive times the Code has been observed
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// LastTransitionTime is the time at which the Code was first observed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// ObservedGeneration is the generation of the Config the Code was observed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // InstallStatus describes the state of installation of pipelines
@@ -111,6 +339,17 @@ const (
 
 	// InstalledStatus indicates that all pipeline resources are installed successfully
 	InstalledStatus InstallStatus = "installed"
+
+	// UpgradePending indicates that the installed resources are kept until
+	// the next maintenance window although the operator ships newer releases
+	// Check details field for the target versions
+	UpgradePending InstallStatus = "upgrade-pending"
+
+	// FailedStatus indicates that a phase of the installation failed permanently
+	// or did not complete before its deadline. The installation is retried only
+	// after a change to the spec or when the retry annotation is set
+	// Check details field for additional details
+	FailedStatus InstallStatus = "failed"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -148,6 +387,15 @@ func (c *Config) InstallStatus() InstallStatus {
 	return con[0].Code
 }
 
+// ManagementState returns the management state of the spec, Managed when it
+// is not set
+func (c *Config) ManagementState() ManagementState {
+	if c.Spec.ManagementState == "" {
+		return Managed
+	}
+	return c.Spec.ManagementState
+}
+
 func (c *Config) HasInstalledVersion(target string) bool {
 	return c.InstallStatus() == InstalledStatus &&
 		c.Status.Conditions[0].Version == target
@@ -11,10 +11,118 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.Config":          schema_pkg_apis_operator_v1alpha1_Config(ref),
-		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigCondition": schema_pkg_apis_operator_v1alpha1_ConfigCondition(ref),
-		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigSpec":      schema_pkg_apis_operator_v1alpha1_ConfigSpec(ref),
-		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigStatus":    schema_pkg_apis_operator_v1alpha1_ConfigStatus(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.CLIDownloadsSpec":   schema_pkg_apis_operator_v1alpha1_CLIDownloadsSpec(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ComponentInventory": schema_pkg_apis_operator_v1alpha1_ComponentInventory(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ComponentSpec":      schema_pkg_apis_operator_v1alpha1_ComponentSpec(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.Config":             schema_pkg_apis_operator_v1alpha1_Config(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigCondition":    schema_pkg_apis_operator_v1alpha1_ConfigCondition(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigSpec":         schema_pkg_apis_operator_v1alpha1_ConfigSpec(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigStatus":       schema_pkg_apis_operator_v1alpha1_ConfigStatus(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.DeploymentOverride": schema_pkg_apis_operator_v1alpha1_DeploymentOverride(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.HighAvailability":   schema_pkg_apis_operator_v1alpha1_HighAvailability(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.MaintenanceWindow":  schema_pkg_apis_operator_v1alpha1_MaintenanceWindow(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.Overlay":            schema_pkg_apis_operator_v1alpha1_Overlay(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.OverlayTarget":      schema_pkg_apis_operator_v1alpha1_OverlayTarget(ref),
+		"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ProxySpec":          schema_pkg_apis_operator_v1alpha1_ProxySpec(ref),
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_CLIDownloadsSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CLIDownloadsSpec defines the server of the tkn archives",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image serving the tkn archives over HTTP on port 8080, laid out as <version>/tkn-<os>-<arch>-<version>.<ext>. Defaults to the image shipped with the operator",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_ComponentInventory(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ComponentInventory summarises the objects last applied for a component",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"component": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Component is the name of the component, e.g. pipeline or addons",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"count": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Count is the number of objects applied for the component",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"digest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Digest identifies the objects and their applied content",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastApplied": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastApplied is the time at which the component was last applied",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"component", "count", "digest"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_ComponentSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ComponentSpec defines the desired state of a Tekton component",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Version is the release to install, one of the releases bundled with the operator. The newest bundled release is installed when empty",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"deployments": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Deployments customizes the Deployments of the component, keyed by Deployment name",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.DeploymentOverride"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.DeploymentOverride"},
 	}
 }
 
@@ -104,10 +212,32 @@ func schema_pkg_apis_operator_v1alpha1_ConfigCondition(ref common.ReferenceCallb
 							Format:      "",
 						},
 					},
+					"attempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Attempts is the number of consecutive times the Code has been observed",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastTransitionTime is the time at which the Code was first observed",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the generation of the Config the Code was observed for",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
 				},
 				Required: []string{"code", "version"},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -125,10 +255,68 @@ func schema_pkg_apis_operator_v1alpha1_ConfigSpec(ref common.ReferenceCallback)
 							Format:      "",
 						},
 					},
+					"managementState": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ManagementState is Managed, Unmanaged to stop the operator from changing the components, or Removed to uninstall them. Defaults to Managed",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"maintenanceWindow": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaintenanceWindow restricts the upgrades to the releases of a new operator version to a recurring window. They are applied right away when it is not set",
+							Ref:         ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.MaintenanceWindow"),
+						},
+					},
+					"pipeline": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Pipeline configures the Tekton Pipelines component",
+							Ref:         ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ComponentSpec"),
+						},
+					},
+					"triggers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Triggers configures the Tekton Triggers component",
+							Ref:         ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ComponentSpec"),
+						},
+					},
+					"highAvailability": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HighAvailability runs several replicas of the controllers and webhooks when set",
+							Ref:         ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.HighAvailability"),
+						},
+					},
+					"proxy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Proxy is used by the controllers when the cluster has no proxy configuration",
+							Ref:         ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ProxySpec"),
+						},
+					},
+					"cliDownloads": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CLIDownloads serves the tkn archives linked from the console from the target namespace when set, for clusters without access to the mirror",
+							Ref:         ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.CLIDownloadsSpec"),
+						},
+					},
+					"overlays": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Overlays patch the shipped manifests after all the other changes of the operator, in order. Settings without a dedicated field can be changed this way",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.Overlay"),
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"targetNamespace"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.CLIDownloadsSpec", "github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ComponentSpec", "github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.HighAvailability", "github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.MaintenanceWindow", "github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.Overlay", "github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ProxySpec"},
 	}
 }
 
@@ -159,10 +347,322 @@ func schema_pkg_apis_operator_v1alpha1_ConfigStatus(ref common.ReferenceCallback
 							},
 						},
 					},
+					"lastReconcileRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastReconcileRequest is the value of the reconcile-request annotation that was last handled by re-applying all the components",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"desiredPipelineVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DesiredPipelineVersion is the pipeline release selected by the spec; the installed release is reported by the conditions",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"desiredTriggersVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DesiredTriggersVersion is the triggers release selected by the spec; the installed release is reported by the conditions",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"proxyHash": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProxyHash identifies the proxy settings and trusted CA bundle that were last applied to the controllers",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"inventory": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Inventory summarises the objects last applied for each component; the objects are listed in the inventory ConfigMap of the Config",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ComponentInventory"),
+									},
+								},
+							},
+						},
+					},
+					"unmatchedOverlays": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UnmatchedOverlays lists the targets of the overlays that are not part of the installed manifests, e.g. once a release drops them",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"managementState": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ManagementState is the management state the operator last acted on",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ComponentInventory", "github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigCondition"},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_DeploymentOverride(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "DeploymentOverride defines the settings applied on top of a shipped Deployment",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Replicas is the number of desired pods",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Resources are the compute resource requirements of every container",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+					"nodeSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NodeSelector must match the labels of a node for the pods to be scheduled on it",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"tolerations": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tolerations of the pods",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.Toleration"),
+									},
+								},
+							},
+						},
+					},
+					"affinity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Affinity contains the scheduling constraints of the pods",
+							Ref:         ref("k8s.io/api/core/v1.Affinity"),
+						},
+					},
+					"priorityClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PriorityClassName is the priority class of the pods",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"env": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Env is set on every container, replacing variables of the same name",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Toleration"},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_HighAvailability(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "HighAvailability defines the replicas of the controllers and webhooks",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Replicas of each controller and webhook, defaults to 2",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_MaintenanceWindow(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "MaintenanceWindow is a recurring period in which upgrades are applied",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule is the start of the window as a cron expression of the minute, hour, day of month, month and day of week in UTC, e.g. \"0 22 * * 1-5\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Duration is how long the window stays open after each start, e.g. 2h",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+				},
+				Required: []string{"schedule", "duration"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_Overlay(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Overlay patches an object of the shipped manifests",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"target": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Target is the object patched",
+							Ref:         ref("github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.OverlayTarget"),
+						},
+					},
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the patch, json or strategic. Defaults to strategic",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"patch": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Patch is the JSON patch or the strategic merge patch, in YAML or JSON",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
+				Required: []string{"target", "patch"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.ConfigCondition"},
+			"github.com/openshift/openshift-pipelines-operator/pkg/apis/operator/v1alpha1.OverlayTarget"},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_OverlayTarget(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "OverlayTarget selects an object of the shipped manifests, once transformed for the target namespace",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion of the object, any version of the kind when empty",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind of the object",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace of the object, any namespace when empty",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the object",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"kind", "name"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_operator_v1alpha1_ProxySpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ProxySpec defines the proxy used by the controllers",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"httpProxy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HTTPProxy is the URL of the proxy for HTTP requests",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"httpsProxy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HTTPSProxy is the URL of the proxy for HTTPS requests",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"noProxy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NoProxy is a comma-separated list of hosts and CIDRs the proxy is not used for",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"trustedCA": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TrustedCA is a PEM encoded bundle of additional CAs trusted by the controllers",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
 	}
 }
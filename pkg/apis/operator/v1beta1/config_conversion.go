@@ -0,0 +1,304 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// AnnotationConditionHistory holds the conditions of a v1alpha1 Config
+// converted to v1beta1, other than the latest one, so that a Config converted
+// to v1beta1 and back is unchanged
+const AnnotationConditionHistory = "operator.tekton.dev/v1alpha1-condition-history"
+
+// reasons of the Ready condition for the install statuses of v1alpha1
+var reasons = map[v1alpha1.InstallStatus]string{
+	v1alpha1.EmptyStatus:             "Empty",
+	v1alpha1.AppliedPipeline:         "AppliedPipeline",
+	v1alpha1.PipelineApplyError:      "PipelineApplyError",
+	v1alpha1.ValidatedPipeline:       "ValidatedPipeline",
+	v1alpha1.PipelineValidateError:   "PipelineValidateError",
+	v1alpha1.InvalidResource:         "InvalidResource",
+	v1alpha1.AppliedTriggers:         "AppliedTriggers",
+	v1alpha1.TriggersError:           "TriggersError",
+	v1alpha1.ValidatedTriggers:       "ValidatedTriggers",
+	v1alpha1.TriggersValidateError:   "TriggersValidateError",
+	v1alpha1.AppliedAddons:           "AppliedAddons",
+	v1alpha1.AddonsError:             "AddonsError",
+	v1alpha1.CommunityResourcesError: "CommunityResourcesError",
+	v1alpha1.InstalledStatus:         "Installed",
+	v1alpha1.UpgradePending:          "UpgradePending",
+	v1alpha1.FailedStatus:            "Failed",
+}
+
+// errorStatuses are retried by the controller until the phase deadline
+var errorStatuses = map[v1alpha1.InstallStatus]bool{
+	v1alpha1.PipelineApplyError:      true,
+	v1alpha1.PipelineValidateError:   true,
+	v1alpha1.TriggersError:           true,
+	v1alpha1.TriggersValidateError:   true,
+	v1alpha1.AddonsError:             true,
+	v1alpha1.CommunityResourcesError: true,
+}
+
+var _ conversion.Convertible = &Config{}
+
+// ConvertTo converts c to the v1alpha1 Config hub
+func (c *Config) ConvertTo(hub conversion.Hub) error {
+	dst, ok := hub.(*v1alpha1.Config)
+	if !ok {
+		return fmt.Errorf("unsupported conversion of config to %T", hub)
+	}
+
+	dst.ObjectMeta = *c.ObjectMeta.DeepCopy()
+	history := removeAnnotation(&dst.ObjectMeta, AnnotationConditionHistory)
+
+	spec := c.Spec.DeepCopy()
+	dst.Spec = v1alpha1.ConfigSpec{
+		TargetNamespace:   spec.TargetNamespace,
+		ManagementState:   v1alpha1.ManagementState(spec.ManagementState),
+		MaintenanceWindow: (*v1alpha1.MaintenanceWindow)(spec.MaintenanceWindow),
+		Pipeline:          componentToAlpha(spec.Components.Pipeline),
+		Triggers:          componentToAlpha(spec.Components.Triggers),
+		HighAvailability:  (*v1alpha1.HighAvailability)(spec.HighAvailability),
+		Proxy:             (*v1alpha1.ProxySpec)(spec.Proxy),
+		CLIDownloads:      (*v1alpha1.CLIDownloadsSpec)(spec.CLIDownloads),
+		Overlays:          overlaysToAlpha(spec.Overlays),
+	}
+
+	dst.Status = v1alpha1.ConfigStatus{
+		OperatorUUID:           c.Status.OperatorUUID,
+		LastReconcileRequest:   c.Status.LastReconcileRequest,
+		DesiredPipelineVersion: c.Status.Versions.DesiredPipeline,
+		DesiredTriggersVersion: c.Status.Versions.DesiredTriggers,
+		ProxyHash:              c.Status.ProxyHash,
+		Inventory:              inventoryToAlpha(c.Status.Inventory),
+		UnmatchedOverlays:      c.Status.UnmatchedOverlays,
+		ManagementState:        v1alpha1.ManagementState(c.Status.ManagementState),
+	}
+	ready := c.GetCondition(ConditionReady)
+	if ready == nil {
+		return nil
+	}
+	dst.Status.Conditions = []v1alpha1.ConfigCondition{{
+		Code:               installStatus(ready.Reason),
+		Details:            ready.Message,
+		Version:            c.Status.Versions.Operator,
+		PipelineVersion:    c.Status.Versions.Pipeline,
+		TriggersVersion:    c.Status.Versions.Triggers,
+		Attempts:           ready.Attempts,
+		LastTransitionTime: ready.LastTransitionTime,
+		ObservedGeneration: ready.ObservedGeneration,
+	}}
+	if history != "" {
+		var older []v1alpha1.ConfigCondition
+		if err := json.Unmarshal([]byte(history), &older); err != nil {
+			return fmt.Errorf("invalid annotation %s: %v", AnnotationConditionHistory, err)
+		}
+		dst.Status.Conditions = append(dst.Status.Conditions, older...)
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 Config hub to c
+func (c *Config) ConvertFrom(hub conversion.Hub) error {
+	src, ok := hub.(*v1alpha1.Config)
+	if !ok {
+		return fmt.Errorf("unsupported conversion of config from %T", hub)
+	}
+
+	c.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	spec := src.Spec.DeepCopy()
+	c.Spec = ConfigSpec{
+		TargetNamespace:   spec.TargetNamespace,
+		ManagementState:   ManagementState(spec.ManagementState),
+		MaintenanceWindow: (*MaintenanceWindow)(spec.MaintenanceWindow),
+		Components: ComponentsSpec{
+			Pipeline: componentFromAlpha(spec.Pipeline),
+			Triggers: componentFromAlpha(spec.Triggers),
+		},
+		HighAvailability: (*HighAvailability)(spec.HighAvailability),
+		Proxy:            (*ProxySpec)(spec.Proxy),
+		CLIDownloads:     (*CLIDownloadsSpec)(spec.CLIDownloads),
+		Overlays:         overlaysFromAlpha(spec.Overlays),
+	}
+
+	c.Status = ConfigStatus{
+		Versions: Versions{
+			DesiredPipeline: src.Status.DesiredPipelineVersion,
+			DesiredTriggers: src.Status.DesiredTriggersVersion,
+		},
+		OperatorUUID:         src.Status.OperatorUUID,
+		LastReconcileRequest: src.Status.LastReconcileRequest,
+		ProxyHash:            src.Status.ProxyHash,
+		Inventory:            inventoryFromAlpha(src.Status.Inventory),
+		UnmatchedOverlays:    src.Status.UnmatchedOverlays,
+		ManagementState:      ManagementState(src.Status.ManagementState),
+	}
+	con := src.Status.Conditions
+	if len(con) == 0 {
+		return nil
+	}
+	latest := con[0]
+	c.Status.Versions.Operator = latest.Version
+	c.Status.Versions.Pipeline = latest.PipelineVersion
+	c.Status.Versions.Triggers = latest.TriggersVersion
+	c.Status.Conditions = conditionsFor(latest)
+	if len(con) > 1 {
+		value, err := json.Marshal(con[1:])
+		if err != nil {
+			return err
+		}
+		setAnnotation(&c.ObjectMeta, AnnotationConditionHistory, string(value))
+	}
+	return nil
+}
+
+// conditionsFor returns the Ready and Progressing conditions matching the
+// latest condition of a v1alpha1 Config
+func conditionsFor(latest v1alpha1.ConfigCondition) []Condition {
+	ready := Condition{
+		Type:               ConditionReady,
+		Status:             corev1.ConditionUnknown,
+		Reason:             reason(latest.Code),
+		Message:            latest.Details,
+		Attempts:           latest.Attempts,
+		LastTransitionTime: latest.LastTransitionTime,
+		ObservedGeneration: latest.ObservedGeneration,
+	}
+	progressing := Condition{
+		Type:               ConditionProgressing,
+		Status:             corev1.ConditionTrue,
+		Reason:             ready.Reason,
+		LastTransitionTime: latest.LastTransitionTime,
+		ObservedGeneration: latest.ObservedGeneration,
+	}
+
+	switch {
+	case latest.Code == v1alpha1.InstalledStatus, latest.Code == v1alpha1.UpgradePending:
+		ready.Status = corev1.ConditionTrue
+		progressing.Status = corev1.ConditionFalse
+	case latest.Code == v1alpha1.FailedStatus, latest.Code == v1alpha1.InvalidResource:
+		ready.Status = corev1.ConditionFalse
+		progressing.Status = corev1.ConditionFalse
+	case errorStatuses[latest.Code]:
+		ready.Status = corev1.ConditionFalse
+	}
+	return []Condition{ready, progressing}
+}
+
+// reason returns the reason of the Ready condition for code. Unknown codes
+// are used as they are
+func reason(code v1alpha1.InstallStatus) string {
+	if r, found := reasons[code]; found {
+		return r
+	}
+	return string(code)
+}
+
+// installStatus returns the v1alpha1 install status of the reason of the
+// Ready condition
+func installStatus(reason string) v1alpha1.InstallStatus {
+	for code, r := range reasons {
+		if r == reason {
+			return code
+		}
+	}
+	return v1alpha1.InstallStatus(reason)
+}
+
+func componentToAlpha(c ComponentSpec) v1alpha1.ComponentSpec {
+	out := v1alpha1.ComponentSpec{Version: c.Version}
+	if c.Deployments != nil {
+		out.Deployments = make(map[string]v1alpha1.DeploymentOverride, len(c.Deployments))
+		for name, d := range c.Deployments {
+			out.Deployments[name] = v1alpha1.DeploymentOverride(d)
+		}
+	}
+	return out
+}
+
+func componentFromAlpha(c v1alpha1.ComponentSpec) ComponentSpec {
+	out := ComponentSpec{Version: c.Version}
+	if c.Deployments != nil {
+		out.Deployments = make(map[string]DeploymentOverride, len(c.Deployments))
+		for name, d := range c.Deployments {
+			out.Deployments[name] = DeploymentOverride(d)
+		}
+	}
+	return out
+}
+
+func overlaysToAlpha(overlays []Overlay) []v1alpha1.Overlay {
+	if overlays == nil {
+		return nil
+	}
+	out := make([]v1alpha1.Overlay, len(overlays))
+	for i, o := range overlays {
+		out[i] = v1alpha1.Overlay{
+			Target: v1alpha1.OverlayTarget(o.Target),
+			Type:   v1alpha1.OverlayType(o.Type),
+			Patch:  o.Patch,
+		}
+	}
+	return out
+}
+
+func overlaysFromAlpha(overlays []v1alpha1.Overlay) []Overlay {
+	if overlays == nil {
+		return nil
+	}
+	out := make([]Overlay, len(overlays))
+	for i, o := range overlays {
+		out[i] = Overlay{
+			Target: OverlayTarget(o.Target),
+			Type:   OverlayType(o.Type),
+			Patch:  o.Patch,
+		}
+	}
+	return out
+}
+
+func inventoryToAlpha(inventory []ComponentInventory) []v1alpha1.ComponentInventory {
+	if inventory == nil {
+		return nil
+	}
+	out := make([]v1alpha1.ComponentInventory, len(inventory))
+	for i, c := range inventory {
+		out[i] = v1alpha1.ComponentInventory(c)
+	}
+	return out
+}
+
+func inventoryFromAlpha(inventory []v1alpha1.ComponentInventory) []ComponentInventory {
+	if inventory == nil {
+		return nil
+	}
+	out := make([]ComponentInventory, len(inventory))
+	for i, c := range inventory {
+		out[i] = ComponentInventory(c)
+	}
+	return out
+}
+
+func setAnnotation(meta *metav1.ObjectMeta, key, value string) {
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[key] = value
+}
+
+// removeAnnotation removes the annotation key and returns its value
+func removeAnnotation(meta *metav1.ObjectMeta, key string) string {
+	value := meta.Annotations[key]
+	delete(meta.Annotations, key)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	return value
+}
@@ -0,0 +1,157 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+func TestConvertAlphaRoundTrip(t *testing.T) {
+	replicas := int32(3)
+	now := metav1.NewTime(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC))
+	alpha := &v1alpha1.Config{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Generation: 4, Labels: map[string]string{"team": "ci"}},
+		Spec: v1alpha1.ConfigSpec{
+			TargetNamespace: "openshift-pipelines",
+			ManagementState: v1alpha1.Unmanaged,
+			MaintenanceWindow: &v1alpha1.MaintenanceWindow{
+				Schedule: "0 22 * * 1-5",
+				Duration: metav1.Duration{Duration: 2 * time.Hour},
+			},
+			Pipeline: v1alpha1.ComponentSpec{
+				Version: "v0.18.0",
+				Deployments: map[string]v1alpha1.DeploymentOverride{
+					"tekton-pipelines-controller": {
+						Replicas: &replicas,
+						Resources: &corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+						},
+						NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+					},
+				},
+			},
+			Triggers:         v1alpha1.ComponentSpec{Version: "v0.8.1"},
+			HighAvailability: &v1alpha1.HighAvailability{Replicas: 2},
+			Proxy:            &v1alpha1.ProxySpec{HTTPSProxy: "http://proxy:3128"},
+			CLIDownloads:     &v1alpha1.CLIDownloadsSpec{Image: "registry.example.com/tkn-cli-serve"},
+			Overlays: []v1alpha1.Overlay{{
+				Target: v1alpha1.OverlayTarget{Kind: "Service", Name: "tekton-pipelines-webhook"},
+				Type:   v1alpha1.StrategicMergeOverlay,
+				Patch:  "metadata:\n  annotations:\n    team: ci\n",
+			}},
+		},
+		Status: v1alpha1.ConfigStatus{
+			OperatorUUID:           "d0c6",
+			DesiredPipelineVersion: "v0.18.0",
+			DesiredTriggersVersion: "v0.8.1",
+			ProxyHash:              "abcd",
+			Inventory: []v1alpha1.ComponentInventory{
+				{Component: "pipeline", Count: 42, Digest: "0f3c", LastApplied: now},
+			},
+			UnmatchedOverlays: []string{"overlays[1]: ConfigMap config-removed"},
+			ManagementState:   v1alpha1.Unmanaged,
+			Conditions: []v1alpha1.ConfigCondition{
+				{Code: v1alpha1.InstalledStatus, Version: "1.1.0", PipelineVersion: "v0.18.0", TriggersVersion: "v0.8.1",
+					Attempts: 1, LastTransitionTime: now, ObservedGeneration: 4},
+				{Code: v1alpha1.PipelineApplyError, Details: "connection refused", Version: "1.1.0", Attempts: 2,
+					LastTransitionTime: now, ObservedGeneration: 4},
+			},
+		},
+	}
+
+	beta := &Config{}
+	assertNoError(beta.ConvertFrom(alpha.DeepCopy()), "failed to convert from v1alpha1;", t)
+	if beta.Spec.Components.Pipeline.Version != "v0.18.0" || beta.Status.Versions.Pipeline != "v0.18.0" {
+		t.Errorf("unexpected v1beta1 config %+v", beta)
+	}
+	ready := beta.GetCondition(ConditionReady)
+	if ready == nil || ready.Status != corev1.ConditionTrue || ready.Reason != "Installed" {
+		t.Errorf("expected the config to be ready, got %+v", beta.Status.Conditions)
+	}
+
+	back := &v1alpha1.Config{}
+	assertNoError(beta.ConvertTo(back), "failed to convert to v1alpha1;", t)
+	if !equality.Semantic.DeepEqual(alpha, back) {
+		t.Errorf("round trip changed the config:\n%s", diff.ObjectReflectDiff(alpha, back))
+	}
+}
+
+func TestConvertBetaRoundTrip(t *testing.T) {
+	now := metav1.NewTime(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC))
+	beta := &Config{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: ConfigSpec{
+			TargetNamespace: "openshift-pipelines",
+			Components:      ComponentsSpec{Pipeline: ComponentSpec{Version: "v0.18.0"}},
+		},
+		Status: ConfigStatus{
+			Versions: Versions{Operator: "1.1.0", DesiredPipeline: "v0.18.0"},
+			Conditions: conditionsFor(v1alpha1.ConfigCondition{
+				Code: v1alpha1.PipelineApplyError, Details: "connection refused", Attempts: 3, LastTransitionTime: now,
+			}),
+		},
+	}
+
+	alpha := &v1alpha1.Config{}
+	assertNoError(beta.DeepCopy().ConvertTo(alpha), "failed to convert to v1alpha1;", t)
+	if code := alpha.InstallStatus(); code != v1alpha1.PipelineApplyError {
+		t.Errorf("expected install status %s, got %s", v1alpha1.PipelineApplyError, code)
+	}
+
+	back := &Config{}
+	assertNoError(back.ConvertFrom(alpha), "failed to convert from v1alpha1;", t)
+	if !equality.Semantic.DeepEqual(beta, back) {
+		t.Errorf("round trip changed the config:\n%s", diff.ObjectReflectDiff(beta, back))
+	}
+}
+
+func TestConditionsFor(t *testing.T) {
+	for _, tc := range []struct {
+		code        v1alpha1.InstallStatus
+		ready       corev1.ConditionStatus
+		progressing corev1.ConditionStatus
+	}{
+		{v1alpha1.InstalledStatus, corev1.ConditionTrue, corev1.ConditionFalse},
+		{v1alpha1.UpgradePending, corev1.ConditionTrue, corev1.ConditionFalse},
+		{v1alpha1.FailedStatus, corev1.ConditionFalse, corev1.ConditionFalse},
+		{v1alpha1.InvalidResource, corev1.ConditionFalse, corev1.ConditionFalse},
+		{v1alpha1.TriggersError, corev1.ConditionFalse, corev1.ConditionTrue},
+		{v1alpha1.AppliedAddons, corev1.ConditionUnknown, corev1.ConditionTrue},
+		{"unknown-code", corev1.ConditionUnknown, corev1.ConditionTrue},
+	} {
+		con := conditionsFor(v1alpha1.ConfigCondition{Code: tc.code})
+		if con[0].Status != tc.ready || con[1].Status != tc.progressing {
+			t.Errorf("%s: expected ready %s and progressing %s, got %+v", tc.code, tc.ready, tc.progressing, con)
+		}
+		if got := installStatus(con[0].Reason); got != tc.code {
+			t.Errorf("%s: expected the reason %s to convert back, got %s", tc.code, con[0].Reason, got)
+		}
+	}
+}
+
+func TestConvertible(t *testing.T) {
+	s := runtime.NewScheme()
+	assertNoError(v1alpha1.SchemeBuilder.AddToScheme(s), "failed to register v1alpha1;", t)
+	assertNoError(SchemeBuilder.AddToScheme(s), "failed to register v1beta1;", t)
+
+	ok, err := conversion.IsConvertible(s, &Config{})
+	assertNoError(err, "failed to check the conversion;", t)
+	if !ok {
+		t.Error("expected config to be convertible between v1alpha1 and v1beta1")
+	}
+}
+
+func assertNoError(err error, msg string, t *testing.T) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(msg, err)
+	}
+}
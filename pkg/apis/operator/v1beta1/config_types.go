@@ -0,0 +1,339 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigSpec defines the desired state of Config
+// +k8s:openapi-gen=true
+type ConfigSpec struct {
+	// TargetNamespace is the namespace the components are installed into
+	TargetNamespace string `json:"targetNamespace"`
+
+	// ManagementState is Managed, Unmanaged to stop the operator from
+	// changing the components, or Removed to uninstall them. Defaults to
+	// Managed
+	// +kubebuilder:validation:Enum=Managed;Unmanaged;Removed
+	ManagementState ManagementState `json:"managementState,omitempty"`
+
+	// MaintenanceWindow restricts the upgrades to the releases of a new
+	// operator version to a recurring window. They are applied right away
+	// when it is not set
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// Components configures the Tekton components
+	Components ComponentsSpec `json:"components,omitempty"`
+
+	// HighAvailability runs several replicas of the controllers and webhooks
+	// when set
+	HighAvailability *HighAvailability `json:"highAvailability,omitempty"`
+
+	// Proxy is used by the controllers when the cluster has no proxy
+	// configuration
+	Proxy *ProxySpec `json:"proxy,omitempty"`
+
+	// CLIDownloads serves the tkn archives linked from the console from the
+	// target namespace when set, for clusters without access to the mirror
+	CLIDownloads *CLIDownloadsSpec `json:"cliDownloads,omitempty"`
+
+	// Overlays patch the shipped manifests after all the other changes of
+	// the operator, in order. Settings without a dedicated field can be
+	// changed this way
+	Overlays []Overlay `json:"overlays,omitempty"`
+}
+
+// ManagementState tells whether the operator manages the installed components
+type ManagementState string
+
+const (
+	// Managed components are installed, upgraded and repaired by the operator
+	Managed ManagementState = "Managed"
+
+	// Unmanaged components are left as they are, e.g. while they are edited
+	// by hand, and are not upgraded
+	Unmanaged ManagementState = "Unmanaged"
+
+	// Removed components are uninstalled, the Config is kept
+	Removed ManagementState = "Removed"
+)
+
+// OverlayType is the kind of patch of an overlay
+type OverlayType string
+
+const (
+	// JSONPatchOverlay is a JSON patch, a list of operations (RFC 6902)
+	JSONPatchOverlay OverlayType = "json"
+
+	// StrategicMergeOverlay is a strategic merge patch; it is applied as a
+	// JSON merge patch (RFC 7386) to the kinds without a Go type, like the
+	// Tekton resources
+	StrategicMergeOverlay OverlayType = "strategic"
+)
+
+// Overlay patches an object of the shipped manifests
+// +k8s:openapi-gen=true
+type Overlay struct {
+	// Target is the object patched
+	Target OverlayTarget `json:"target"`
+
+	// Type of the patch, json or strategic. Defaults to strategic
+	// +kubebuilder:validation:Enum=json;strategic
+	Type OverlayType `json:"type,omitempty"`
+
+	// Patch is the JSON patch or the strategic merge patch, in YAML or JSON
+	Patch string `json:"patch"`
+}
+
+// OverlayTarget selects an object of the shipped manifests, once transformed
+// for the target namespace
+// +k8s:openapi-gen=true
+type OverlayTarget struct {
+	// APIVersion of the object, any version of the kind when empty
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the object
+	Kind string `json:"kind"`
+
+	// Namespace of the object, any namespace when empty
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object
+	Name string `json:"name"`
+}
+
+// CLIDownloadsSpec defines the server of the tkn archives
+// +k8s:openapi-gen=true
+type CLIDownloadsSpec struct {
+	// Image serving the tkn archives over HTTP on port 8080, laid out as
+	// <version>/tkn-<os>-<arch>-<version>.<ext>. Defaults to the image
+	// shipped with the operator
+	Image string `json:"image,omitempty"`
+}
+
+// ComponentsSpec defines the desired state of the Tekton components
+// +k8s:openapi-gen=true
+type ComponentsSpec struct {
+	// Pipeline configures the Tekton Pipelines component
+	Pipeline ComponentSpec `json:"pipeline,omitempty"`
+
+	// Triggers configures the Tekton Triggers component
+	Triggers ComponentSpec `json:"triggers,omitempty"`
+}
+
+// ComponentSpec defines the desired state of a Tekton component
+// +k8s:openapi-gen=true
+type ComponentSpec struct {
+	// Version is the release to install, one of the releases bundled with
+	// the operator. The newest bundled release is installed when empty
+	Version string `json:"version,omitempty"`
+
+	// Deployments customizes the Deployments of the component, keyed by
+	// Deployment name
+	Deployments map[string]DeploymentOverride `json:"deployments,omitempty"`
+}
+
+// DeploymentOverride defines the settings applied on top of a shipped Deployment
+// +k8s:openapi-gen=true
+type DeploymentOverride struct {
+	// Replicas is the number of desired pods
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources are the compute resource requirements of every container
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector must match the labels of a node for the pods to be scheduled on it
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the pods
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity contains the scheduling constraints of the pods
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName is the priority class of the pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Env is set on every container, replacing variables of the same name
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// ProxySpec defines the proxy used by the controllers
+// +k8s:openapi-gen=true
+type ProxySpec struct {
+	// HTTPProxy is the URL of the proxy for HTTP requests
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// HTTPSProxy is the URL of the proxy for HTTPS requests
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is a comma-separated list of hosts and CIDRs the proxy is not used for
+	NoProxy string `json:"noProxy,omitempty"`
+
+	// TrustedCA is a PEM encoded bundle of additional CAs trusted by the controllers
+	TrustedCA string `json:"trustedCA,omitempty"`
+}
+
+// HighAvailability defines the replicas of the controllers and webhooks
+// +k8s:openapi-gen=true
+type HighAvailability struct {
+	// Replicas of each controller and webhook, defaults to 2
+	// +kubebuilder:validation:Minimum=2
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period in which upgrades are applied
+// +k8s:openapi-gen=true
+type MaintenanceWindow struct {
+	// Schedule is the start of the window as a cron expression of the
+	// minute, hour, day of month, month and day of week in UTC, e.g.
+	// "0 22 * * 1-5"
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open after each start, e.g. 2h
+	Duration metav1.Duration `json:"duration"`
+}
+
+// ConfigStatus defines the observed state of Config
+// +k8s:openapi-gen=true
+type ConfigStatus struct {
+	// Conditions are the latest observations of the installation
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Versions are the installed and desired versions
+	Versions Versions `json:"versions,omitempty"`
+
+	// OperatorUUID is the uuid (auto-generated) of the operator that
+	// installed the pipeline
+	OperatorUUID string `json:"operatorUUID,omitempty"`
+
+	// LastReconcileRequest is the value of the reconcile-request annotation
+	// that was last handled by re-applying all the components
+	LastReconcileRequest string `json:"lastReconcileRequest,omitempty"`
+
+	// ProxyHash identifies the proxy settings and trusted CA bundle that were
+	// last applied to the controllers
+	ProxyHash string `json:"proxyHash,omitempty"`
+
+	// Inventory summarises the objects last applied for each component; the
+	// objects are listed in the inventory ConfigMap of the Config
+	Inventory []ComponentInventory `json:"inventory,omitempty"`
+
+	// UnmatchedOverlays lists the targets of the overlays that are not part
+	// of the installed manifests, e.g. once a release drops them
+	UnmatchedOverlays []string `json:"unmatchedOverlays,omitempty"`
+
+	// ManagementState is the management state the operator last acted on
+	ManagementState ManagementState `json:"managementState,omitempty"`
+}
+
+// ComponentInventory summarises the objects last applied for a component
+// +k8s:openapi-gen=true
+type ComponentInventory struct {
+	// Component is the name of the component, e.g. pipeline or addons
+	Component string `json:"component"`
+
+	// Count is the number of objects applied for the component
+	Count int32 `json:"count"`
+
+	// Digest identifies the objects and their applied content
+	Digest string `json:"digest"`
+
+	// LastApplied is the time at which the component was last applied
+	LastApplied metav1.Time `json:"lastApplied,omitempty"`
+}
+
+// Versions defines the versions of the operator and the components
+// +k8s:openapi-gen=true
+type Versions struct {
+	// Operator is the version of the operator that last reported the status
+	Operator string `json:"operator,omitempty"`
+
+	// Pipeline is the installed Tekton Pipelines release
+	Pipeline string `json:"pipeline,omitempty"`
+
+	// Triggers is the installed Tekton Triggers release
+	Triggers string `json:"triggers,omitempty"`
+
+	// DesiredPipeline is the Tekton Pipelines release selected by the spec
+	DesiredPipeline string `json:"desiredPipeline,omitempty"`
+
+	// DesiredTriggers is the Tekton Triggers release selected by the spec
+	DesiredTriggers string `json:"desiredTriggers,omitempty"`
+}
+
+// ConditionType is the type of a Condition
+type ConditionType string
+
+const (
+	// ConditionReady is True when every component is installed and
+	// validated, False when the installation failed and Unknown while it
+	// is in progress
+	ConditionReady ConditionType = "Ready"
+
+	// ConditionProgressing is True while the installation is in progress
+	ConditionProgressing ConditionType = "Progressing"
+)
+
+// Condition defines an observation of the installation
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type of the condition
+	Type ConditionType `json:"type"`
+
+	// Status of the condition, one of True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason is a CamelCase word identifying the cause of the status
+	Reason string `json:"reason,omitempty"`
+
+	// Message gives details about the status
+	Message string `json:"message,omitempty"`
+
+	// Attempts is the number of consecutive times the Reason has been observed
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// LastTransitionTime is the time at which the Reason was first observed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// ObservedGeneration is the generation of the Config the condition was
+	// observed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Config is the Schema for the configs API
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:path=config
+// +kubebuilder:subresource:status
+type Config struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConfigSpec   `json:"spec,omitempty"`
+	Status ConfigStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ConfigList contains a list of Config
+type ConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Config `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Config{}, &ConfigList{})
+}
+
+// GetCondition returns the condition of type t, or nil when it is not set
+func (c *Config) GetCondition(t ConditionType) *Condition {
+	for i := range c.Status.Conditions {
+		if c.Status.Conditions[i].Type == t {
+			return &c.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	paddons "github.com/tektoncd/operator/pkg/utils/addons"
+	"github.com/tektoncd/operator/pkg/utils/transform"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// cliServeImage is the key of the image of the tkn download server, set by
+// IMAGE_ADDONS_TKN_CLI_SERVE or the image of spec.cliDownloads
+const cliServeImage = "tkn_cli_serve"
+
+// readCLIDownloads reads the server of the tkn archives. Payloads of older
+// releases do not ship one
+func readCLIDownloads(mgr manager.Manager) (mf.Manifest, error) {
+	path := filepath.Join(flag.ResourceDir, "clidownloads")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return mf.Manifest{}, nil
+	}
+	return mf.ManifestFrom(sourceBasedOnRecursion(path), mf.UseClient(mfc.NewClient(mgr.GetClient())))
+}
+
+// applyCLIDownloads installs the server of the tkn archives in the target
+// namespace when cfg asks for it and removes it otherwise. It returns the base
+// URL the console links the archives at
+func (r *ReconcileConfig) applyCLIDownloads(cfg *op.Config) (string, error) {
+	images := transform.ToLowerCaseKeys(imagesFromEnv(transform.AddonsImagePrefix))
+	spec := cfg.Spec.CLIDownloads
+	if spec != nil && spec.Image != "" {
+		images[cliServeImage] = spec.Image
+	}
+	inst := r.instanceFor(cfg.Name)
+	m, err := transformManifest(cfg, &r.cliDownloads, componentCLIDownloads,
+		cliServeVersion(paddons.TknVersion(inst.pipelineVersion)),
+		transform.DeploymentImages(images))
+	if err != nil {
+		return "", err
+	}
+
+	if spec == nil {
+		// only the objects found are deleted, the kind Route is not served
+		// outside of OpenShift
+		installed := m.Filter(func(u *unstructured.Unstructured) bool {
+			_, err := m.Client.Get(u)
+			return err == nil
+		})
+		if err := installed.Delete(); err != nil {
+			return "", err
+		}
+		inst.cliDownloads = mf.Manifest{}
+		return flag.TknDownloadURL, r.recordInventory(cfg, componentCLIDownloads, inst.cliDownloads)
+	}
+
+	inst.cliDownloads = m
+	if err := m.Apply(); err != nil {
+		return "", err
+	}
+	if err := r.recordInventory(cfg, componentCLIDownloads, m); err != nil {
+		return "", err
+	}
+	host, err := routeHost(m)
+	if err != nil {
+		return "", err
+	}
+	return "https://" + host, nil
+}
+
+// cliServeVersion tags the image of the tkn download server shipped with the
+// operator with the tkn release the console links point at
+func cliServeVersion(version string) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Deployment" {
+			return nil
+		}
+		containers, _, err := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+		if err != nil {
+			return err
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok || container["name"] != "tkn-cli-serve" {
+				continue
+			}
+			image, _ := container["image"].(string)
+			if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+				image = image[:i]
+			}
+			container["image"] = image + ":" + version
+		}
+		return unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers")
+	}
+}
+
+// routeHost returns the host the router admitted the Route of m at
+func routeHost(m mf.Manifest) (string, error) {
+	routes := m.Filter(mf.ByKind("Route")).Resources()
+	if len(routes) == 0 {
+		return "", permanentError{fmt.Errorf("the payload has no Route for the tkn download server")}
+	}
+	route, err := m.Client.Get(&routes[0])
+	if err != nil {
+		return "", err
+	}
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	if host == "" {
+		return "", fmt.Errorf("route %s has no host yet", route.GetName())
+	}
+	return host, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestCLIDownloads(t *testing.T) {
+	const (
+		namespace = "openshift-pipelines"
+		image     = "registry.example.com/tkn-cli-serve:0.15.0"
+		host      = "tkn-cli-serve-openshift-pipelines.apps.example.com"
+	)
+	config := newConfig(flag.ResourceWatched, namespace)
+	config.Spec.CLIDownloads = &op.CLIDownloadsSpec{Image: image}
+	cl := feedConfigMock(config)
+	cliDownloads, err := mfFor("clidownloads", cl)
+	assertNoEror(err, "failed to read the tkn download server;", t)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, cliDownloads: cliDownloads}
+
+	// the route is admitted by the router after it is created
+	if _, err := r.applyCLIDownloads(config); err == nil {
+		t.Fatal("expected an error while the route has no host")
+	}
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"})
+	key := types.NamespacedName{Namespace: namespace, Name: "tkn-cli-serve"}
+	assertNoEror(cl.Get(context.TODO(), key, route), "failed to get the route;", t)
+	assertNoEror(unstructured.SetNestedField(route.Object, host, "spec", "host"), "failed to set the host;", t)
+	assertNoEror(cl.Update(context.TODO(), route), "failed to admit the route;", t)
+
+	url, err := r.applyCLIDownloads(config)
+	assertNoEror(err, "failed to apply the tkn download server;", t)
+	if url != "https://"+host {
+		t.Errorf("expected the archives at the route, got %s", url)
+	}
+	assertContainerHasImage("tkn-cli-serve", "tkn-cli-serve", image, cl, t)
+
+	// the server is removed once the spec no longer asks for it
+	config.Spec.CLIDownloads = nil
+	url, err = r.applyCLIDownloads(config)
+	assertNoEror(err, "failed to remove the tkn download server;", t)
+	if url != flag.TknDownloadURL {
+		t.Errorf("expected the archives at %s, got %s", flag.TknDownloadURL, url)
+	}
+	err = cl.Get(context.TODO(), key, &appsv1.Deployment{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the tkn download server to be deleted, got %v", err)
+	}
+}
+
+func TestCLIDownloadsImageServesTheLinkedRelease(t *testing.T) {
+	config := newConfig(flag.ResourceWatched, "openshift-pipelines")
+	config.Spec.CLIDownloads = &op.CLIDownloadsSpec{}
+	cl := feedConfigMock(config)
+	cliDownloads, err := mfFor("clidownloads", cl)
+	assertNoEror(err, "failed to read the tkn download server;", t)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, cliDownloads: cliDownloads}
+	r.instanceFor(config.Name).pipelineVersion = "v0.17.3"
+
+	// the route has no host yet, the server is applied nevertheless
+	if _, err := r.applyCLIDownloads(config); err == nil {
+		t.Fatal("expected an error while the route has no host")
+	}
+	assertContainerHasImage("tkn-cli-serve", "tkn-cli-serve", "quay.io/openshift-pipeline/tkn-cli-serve:0.14.0", cl, t)
+}
@@ -2,21 +2,25 @@ package config
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"github.com/go-logr/logr"
 	mfc "github.com/manifestival/controller-runtime-client"
 	mf "github.com/manifestival/manifestival"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/operator-framework/operator-sdk/pkg/predicate"
 	"github.com/prometheus/common/log"
 	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
 	"github.com/tektoncd/operator/pkg/flag"
 	paddons "github.com/tektoncd/operator/pkg/utils/addons"
+	"github.com/tektoncd/operator/pkg/utils/informer"
 	"github.com/tektoncd/operator/pkg/utils/transform"
 	"github.com/tektoncd/operator/pkg/utils/validate"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -25,26 +29,47 @@ import (
 	goruntime "runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	replaceTimeout       = 60
 	replaceTimeoutAddons = 300
+
+	retryBaseDelay = 15 * time.Second
+	retryMaxDelay  = 5 * time.Minute
 )
 
 var (
 	ctrlLog          = logf.Log.WithName("ctrl").WithName("config")
 	recreateResource = mf.Any(mf.ByKind("Deployment"), mf.ByKind("Service"))
 	roleBinding      = mf.Any(mf.ByKind("RoleBinding"))
-	pipelineVersion  = ""
-	triggersVersion  = ""
+
+	// phases maps the status codes to the install phase that the reconciler
+	// dispatches them to; attempts and deadlines are tracked per phase
+	phases = map[op.InstallStatus]string{
+		op.EmptyStatus:             "apply-pipeline",
+		op.PipelineApplyError:      "apply-pipeline",
+		op.AppliedPipeline:         "validate-pipeline",
+		op.PipelineValidateError:   "validate-pipeline",
+		op.ValidatedPipeline:       "apply-triggers",
+		op.TriggersError:           "apply-triggers",
+		op.AppliedTriggers:         "validate-triggers",
+		op.TriggersValidateError:   "validate-triggers",
+		op.ValidatedTriggers:       "apply-addons",
+		op.AddonsError:             "apply-addons",
+		op.AppliedAddons:           "apply-community-resources",
+		op.CommunityResourcesError: "apply-community-resources",
+	}
 )
 
 func init() {
@@ -69,18 +94,31 @@ func Add(mgr manager.Manager) error {
 func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 	log := ctrlLog.WithName("new-reconciler")
 	pipelinePath := filepath.Join(flag.ResourceDir, "pipelines")
-	pipeline, err := mf.ManifestFrom(sourceBasedOnRecursion(pipelinePath), mf.UseClient(mfc.NewClient(mgr.GetClient())))
+	pipelineReleases, err := readReleases(pipelinePath, flag.PipelineControllerName, pipelineReleaseLabel,
+		mf.UseClient(mfc.NewClient(mgr.GetClient())))
 	if err != nil {
 		return nil, err
 	}
 
 	triggersPath := filepath.Join(flag.ResourceDir, "triggers")
-	triggers, err := mf.ManifestFrom(sourceBasedOnRecursion(triggersPath), mf.UseClient(mfc.NewClient(mgr.GetClient())))
+	triggersReleases, err := readReleases(triggersPath, flag.TriggerControllerName, triggersReleaseLabel,
+		mf.UseClient(mfc.NewClient(mgr.GetClient())))
+	if err != nil {
+		return nil, err
+	}
+
+	// the console samples are only installed on OpenShift
+	consoleSamples, err := validate.CRD(mgr.GetConfig(), consoleYAMLSamplesCRD)
+	if err != nil {
+		return nil, err
+	}
+
+	addons, err := readAddons(mgr, consoleSamples)
 	if err != nil {
 		return nil, err
 	}
 
-	addons, err := readAddons(mgr)
+	cliDownloads, err := readCLIDownloads(mgr)
 	if err != nil {
 		return nil, err
 	}
@@ -91,13 +129,24 @@ func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 		community = mf.Manifest{}
 	}
 
+	inventoryNamespace, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		log.Info("inventories kept in the target namespace, operator namespace unknown", "reason", err.Error())
+	}
+
 	return &ReconcileConfig{
-		client:    mgr.GetClient(),
-		scheme:    mgr.GetScheme(),
-		pipeline:  pipeline,
-		triggers:  triggers,
-		addons:    addons,
-		community: community,
+		client:             mgr.GetClient(),
+		apiReader:          mgr.GetAPIReader(),
+		inventoryNamespace: inventoryNamespace,
+		scheme:             mgr.GetScheme(),
+		pipeline:           pipelineReleases.latest(),
+		triggers:           triggersReleases.latest(),
+		pipelineReleases:   pipelineReleases,
+		triggersReleases:   triggersReleases,
+		addons:             addons,
+		cliDownloads:       cliDownloads,
+		community:          community,
+		consoleSamples:     consoleSamples,
 	}, nil
 }
 
@@ -120,7 +169,7 @@ func fetchCommuntiyResources(mgr manager.Manager) (mf.Manifest, error) {
 }
 
 // this will read all the addons files
-func readAddons(mgr manager.Manager) (mf.Manifest, error) {
+func readAddons(mgr manager.Manager, consoleSamples bool) (mf.Manifest, error) {
 	// read addons
 	addonsPath := filepath.Join(flag.ResourceDir, "addons")
 	addons, err := mf.ManifestFrom(sourceBasedOnRecursion(addonsPath), mf.UseClient(mfc.NewClient(mgr.GetClient())))
@@ -129,7 +178,7 @@ func readAddons(mgr manager.Manager) (mf.Manifest, error) {
 	}
 
 	// add optionals to addons if any
-	optionalResources, err := readOptional(mgr)
+	optionalResources, err := readOptional(mgr, consoleSamples)
 	if err != nil {
 		return mf.Manifest{}, err
 	}
@@ -145,15 +194,9 @@ func readAddons(mgr manager.Manager) (mf.Manifest, error) {
 	return addons, nil
 }
 
-func readOptional(mgr manager.Manager) (mf.Manifest, error) {
-	// check consolesample CRD available
-	consoleCRDinstalled, err := validate.CRD(mgr.GetConfig(), "consoleyamlsamples.console.openshift.io")
-	if err != nil {
-		return mf.Manifest{}, err
-	}
-
+func readOptional(mgr manager.Manager, consoleSamples bool) (mf.Manifest, error) {
 	// read optionals only if CRD available
-	if consoleCRDinstalled {
+	if consoleSamples {
 		optionalPath := filepath.Join(flag.ResourceDir, "optional")
 		client := mfc.NewClient(mgr.GetClient())
 		optionalAddons, err := mf.ManifestFrom(sourceBasedOnRecursion(optionalPath), mf.UseClient(client))
@@ -162,7 +205,7 @@ func readOptional(mgr manager.Manager) (mf.Manifest, error) {
 		}
 		return optionalAddons, nil
 	}
-	return mf.Manifest{}, err
+	return mf.Manifest{}, nil
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -179,7 +222,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	err = c.Watch(
 		&source.Kind{Type: &op.Config{}},
 		&handler.EnqueueRequestForObject{},
-		predicate.GenerationChangedPredicate{},
+		specOrAnnotationChanged{annotations: []string{flag.AnnotationRetry, flag.AnnotationReconcileRequest, flag.AnnotationForceUpgrade}},
 	)
 	if err != nil {
 		return err
@@ -195,6 +238,41 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// the trusted CA bundle is injected into the ConfigMap asynchronously and
+	// changes with the cluster proxy, so the controllers are updated again.
+	// Only the trusted CA ConfigMaps are watched, not every ConfigMap
+	trustedCA, err := informer.ConfigMaps(mgr, "", flag.TrustedCAConfigMap)
+	if err != nil {
+		return err
+	}
+	err = c.Watch(
+		trustedCA,
+		&handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &op.Config{},
+		},
+		trustedCAChanged)
+	if err != nil {
+		return err
+	}
+
+	proxyInstalled, err := validate.CRD(mgr.GetConfig(), "proxies.config.openshift.io")
+	if err != nil {
+		return err
+	}
+	if proxyInstalled {
+		log.Info("Watching cluster proxy config")
+		proxy := &unstructured.Unstructured{}
+		proxy.SetGroupVersionKind(proxyGVK)
+		err = c.Watch(
+			&source.Kind{Type: proxy},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: enqueueAllConfigs(mgr.GetClient())},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	if flag.NoAutoInstall {
 		return nil
 	}
@@ -206,6 +284,28 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	return nil
 }
 
+// specOrAnnotationChanged passes Config updates that change the spec or any of
+// the annotations used to drive the install
+type specOrAnnotationChanged struct {
+	predicate.GenerationChangedPredicate
+	annotations []string
+}
+
+func (p specOrAnnotationChanged) Update(e event.UpdateEvent) bool {
+	if p.GenerationChangedPredicate.Update(e) {
+		return true
+	}
+	if e.MetaOld == nil || e.MetaNew == nil {
+		return false
+	}
+	for _, key := range p.annotations {
+		if e.MetaOld.GetAnnotations()[key] != e.MetaNew.GetAnnotations()[key] {
+			return true
+		}
+	}
+	return false
+}
+
 // blank assignment to verify that ReconcileConfig implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileConfig{}
 
@@ -213,12 +313,39 @@ var _ reconcile.Reconciler = &ReconcileConfig{}
 type ReconcileConfig struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client    client.Client
-	scheme    *runtime.Scheme
+	client client.Client
+	scheme *runtime.Scheme
+
+	// apiReader reads the installed objects, the Endpoints, the Pods and the
+	// trusted CA ConfigMaps from the apiserver, so that the cache does not
+	// watch every kind the operator installs nor every Endpoints, Pod and
+	// ConfigMap of the cluster
+	apiReader client.Reader
+
+	// inventoryNamespace keeps the inventories of the Configs, it is not
+	// known when the operator runs locally
+	inventoryNamespace string
+
+	// manifests as shipped with the operator; each Config instance keeps its
+	// own transformed copy. pipeline and triggers are the newest bundled
+	// releases, installed when the Config does not select a version
 	pipeline  mf.Manifest
 	triggers  mf.Manifest
 	addons    mf.Manifest
 	community mf.Manifest
+
+	// cliDownloads serves the tkn archives when the Config asks for it
+	cliDownloads mf.Manifest
+
+	// consoleSamples is set when the cluster serves ConsoleYAMLSamples, the
+	// snippets of the cluster tasks are generated then
+	consoleSamples bool
+
+	pipelineReleases releases
+	triggersReleases releases
+
+	mu        sync.Mutex
+	instances map[string]*instance
 }
 
 // Reconcile reads that state of the cluster for a Config object and makes changes based on the state read
@@ -234,17 +361,6 @@ func (r *ReconcileConfig) Reconcile(req reconcile.Request) (reconcile.Result, er
 	cfg := &op.Config{}
 	err := r.client.Get(context.TODO(), types.NamespacedName{Name: req.Name}, cfg)
 
-	// ignore all resources except the `resourceWatched`
-	if req.Name != flag.ResourceWatched {
-		log.Info("ignoring incorrect object")
-
-		// handle resources that are not interesting as error
-		if !errors.IsNotFound(err) {
-			r.markInvalidResource(cfg)
-		}
-		return reconcile.Result{}, nil
-	}
-
 	// handle deletion of resource
 	if errors.IsNotFound(err) {
 		// User deleted the cluster resource so delete the pipeline resources
@@ -258,12 +374,50 @@ func (r *ReconcileConfig) Reconcile(req reconcile.Request) (reconcile.Result, er
 		return reconcile.Result{}, err
 	}
 
-	pipelineVersion = getComponentVersion(r.pipeline, flag.PipelineControllerName, "pipeline.tekton.dev/release")
-	triggersVersion = getComponentVersion(r.triggers, flag.TriggerControllerName, "triggers.tekton.dev/release")
+	// only one instance may install into a target namespace
+	other, err := r.namespaceConflict(cfg)
+	if err != nil {
+		log.Error(err, "failed to list config instances")
+		return reconcile.Result{}, err
+	}
+	if other != "" {
+		log.Info("target namespace already in use", "config", other)
+		r.markInvalidResource(cfg, "targetNamespace "+cfg.Spec.TargetNamespace+" is already used by config "+other)
+		return reconcile.Result{}, nil
+	}
+
+	// pick the releases selected by the spec
+	if err := r.selectReleases(cfg); err != nil {
+		log.Info("invalid release selection", "reason", err.Error())
+		r.markInvalidResource(cfg, err.Error())
+		return reconcile.Result{}, nil
+	}
+
+	if _, err := maintenanceWindow(cfg); err != nil {
+		log.Info("invalid maintenance window", "reason", err.Error())
+		r.markInvalidResource(cfg, "invalid maintenanceWindow: "+err.Error())
+		return reconcile.Result{}, nil
+	}
+
+	proxy, err := r.proxyFor(cfg)
+	if err != nil {
+		log.Error(err, "failed to read the proxy configuration")
+		return reconcile.Result{}, err
+	}
+	r.instanceFor(cfg.Name).proxy = proxy
+
+	if res, done, err := r.reconcileManagementState(req, cfg); done || err != nil {
+		return res, err
+	}
+
+	if requested := cfg.Annotations[flag.AnnotationReconcileRequest]; requested != "" &&
+		requested != cfg.Status.LastReconcileRequest {
+		return r.reconcileRequested(req, cfg, requested)
+	}
 
 	log.Info("reconciling at status: " + string(cfg.InstallStatus()))
 	switch cfg.InstallStatus() {
-	case op.EmptyStatus, op.PipelineApplyError:
+	case op.EmptyStatus, op.PipelineApplyError, op.InvalidResource:
 		return r.applyPipeline(req, cfg)
 	case op.AppliedPipeline, op.PipelineValidateError:
 		return r.validatePipeline(req, cfg)
@@ -275,8 +429,10 @@ func (r *ReconcileConfig) Reconcile(req reconcile.Request) (reconcile.Result, er
 		return r.applyAddons(req, cfg)
 	case op.AppliedAddons, op.CommunityResourcesError:
 		return r.applyCommunityResources(req, cfg)
-	case op.InstalledStatus:
+	case op.InstalledStatus, op.UpgradePending:
 		return r.validateVersion(req, cfg)
+	case op.FailedStatus:
+		return r.retryFailed(req, cfg)
 	}
 	return reconcile.Result{}, nil
 }
@@ -284,45 +440,54 @@ func (r *ReconcileConfig) Reconcile(req reconcile.Request) (reconcile.Result, er
 func (r *ReconcileConfig) applyPipeline(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
 	log := requestLogger(req, "apply-pipeline")
 
-	images := transform.ToLowerCaseKeys(imagesFromEnv(transform.PipelinesImagePrefix))
-	newPipeline, err := transformManifest(cfg, &r.pipeline, transform.DeploymentImages(images))
+	inst := r.instanceFor(cfg.Name)
+	newPipeline, err := r.pipelineManifest(cfg)
 	if err != nil {
 		log.Error(err, "failed to apply manifest transformations on pipeline-core")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:    op.PipelineApplyError,
-			Details: err.Error(),
-			Version: flag.TektonVersion})
-		return reconcile.Result{}, err
+			Version: flag.TektonVersion}, err)
+	}
+	cfg.Status.ProxyHash = inst.proxy.hash
+	if err := clusterConflicts(cfg, newPipeline); err != nil {
+		log.Error(err, "pipeline resources conflict with another instance")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:    op.PipelineApplyError,
+			Version: flag.TektonVersion}, err)
 	}
-	r.pipeline = newPipeline
+	inst.pipeline = newPipeline
 
-	if err := r.pipeline.Filter(mf.Not(recreateResource)).Apply(); err != nil {
+	if err := inst.pipeline.Filter(mf.Not(recreateResource)).Apply(); err != nil {
 		log.Error(err, "failed to apply non deployment and service pipeline manifest")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:    op.PipelineApplyError,
-			Details: err.Error(),
-			Version: flag.TektonVersion})
-		return reconcile.Result{}, fmt.Errorf("failed to apply non deployment and service pipeline manifest: %w", err)
+			Version: flag.TektonVersion}, fmt.Errorf("failed to apply non deployment and service pipeline manifest: %w", err))
 	}
-	if err := r.pipeline.Filter(recreateResource).Apply(); err != nil {
+	if err := inst.pipeline.Filter(recreateResource).Apply(); err != nil {
 		if errors.IsInvalid(err) {
-			if err := r.deleteAndCreate(); err != nil {
-				_ = r.updateStatus(cfg, op.ConfigCondition{
+			if err := deleteAndCreate(inst.pipeline); err != nil {
+				return r.retryOrFail(cfg, op.ConfigCondition{
 					Code:    op.PipelineApplyError,
-					Details: err.Error(),
-					Version: flag.TektonVersion})
-				return reconcile.Result{}, fmt.Errorf("failed to recreate pipeline deployments and services: %w", err)
+					Version: flag.TektonVersion}, fmt.Errorf("failed to recreate pipeline deployments and services: %w", err))
 			}
 		} else {
-			_ = r.updateStatus(cfg, op.ConfigCondition{
+			return r.retryOrFail(cfg, op.ConfigCondition{
 				Code:    op.PipelineApplyError,
-				Details: err.Error(),
-				Version: flag.TektonVersion})
-			return reconcile.Result{}, fmt.Errorf("failed to apply pipeline deployments and service: %w", err)
+				Version: flag.TektonVersion}, fmt.Errorf("failed to apply pipeline deployments and service: %w", err))
 		}
 	}
+	if err := r.prune(cfg, componentPipeline, inst.pipeline); err != nil {
+		log.Error(err, "failed to prune pipeline resources")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:    op.PipelineApplyError,
+			Version: flag.TektonVersion}, fmt.Errorf("failed to prune pipeline resources: %w", err))
+	}
+	if err := r.recordInventory(cfg, componentPipeline, inst.pipeline); err != nil {
+		log.Error(err, "failed to record the pipeline inventory")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:    op.PipelineApplyError,
+			Version: flag.TektonVersion}, fmt.Errorf("failed to record the pipeline inventory: %w", err))
+	}
 	log.Info("successfully applied all pipeline resources")
 
 	err = r.updateStatus(cfg, op.ConfigCondition{
@@ -332,18 +497,20 @@ func (r *ReconcileConfig) applyPipeline(req reconcile.Request, cfg *op.Config) (
 	return reconcile.Result{Requeue: true}, err
 }
 
-func (r *ReconcileConfig) deleteAndCreate() error {
+// deleteAndCreate recreates the deployments and services of manifest, e.g.
+// when an upgrade changes immutable fields like the selector
+func deleteAndCreate(manifest mf.Manifest) error {
 	timeout := time.Duration(replaceTimeout) * time.Second
 
 	propPolicy := mf.PropagationPolicy(metav1.DeletePropagationForeground)
-	if err := r.pipeline.Filter(recreateResource).Delete(propPolicy); err != nil {
-		log.Error(err, "failed to delete pipeline deployment and service resources")
+	if err := manifest.Filter(recreateResource).Delete(propPolicy); err != nil {
+		log.Error(err, "failed to delete deployment and service resources")
 		return err
 	}
 
 	if err := wait.PollImmediate(1*time.Second, timeout, func() (bool, error) {
-		for _, deploy := range r.pipeline.Filter(recreateResource).Resources() {
-			if _, err := r.pipeline.Client.Get(&deploy); !apierrors.IsNotFound(err) {
+		for _, deploy := range manifest.Filter(recreateResource).Resources() {
+			if _, err := manifest.Client.Get(&deploy); !apierrors.IsNotFound(err) {
 				return false, err
 			}
 		}
@@ -352,17 +519,64 @@ func (r *ReconcileConfig) deleteAndCreate() error {
 		return err
 	}
 
-	return r.pipeline.Filter(recreateResource).Apply()
+	return manifest.Filter(recreateResource).Apply()
 }
 
 func (r *ReconcileConfig) validateVersion(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
+	inst := r.instanceFor(cfg.Name)
+
+	// a pending upgrade stays pending until it is applied
+	installedPipeline, installedTriggers := installedVersions(cfg)
+	upgrade := cfg.InstallStatus() == op.UpgradePending ||
+		!cfg.HasInstalledVersion(flag.TektonVersion) ||
+		!matchesUUID(cfg.Status.OperatorUUID) ||
+		installedPipeline != inst.pipelineVersion ||
+		installedTriggers != inst.triggersVersion
+
+	if upgrade {
+		if res, deferred, err := r.deferUpgrade(req, cfg); deferred || err != nil {
+			return res, err
+		}
+		return r.applyPipeline(req, cfg)
+	}
 
-	uptoDate := cfg.HasInstalledVersion(flag.TektonVersion) &&
-		matchesUUID(cfg.Status.OperatorUUID)
-
-	if !uptoDate {
+	// a change of the spec since the install, e.g. of the deployment
+	// overrides, the high availability settings or the overlays, is applied
+	// from the first phase
+	if con := cfg.Status.Conditions; len(con) > 0 && con[0].ObservedGeneration != cfg.Generation {
 		return r.applyPipeline(req, cfg)
 	}
+
+	if cfg.Status.ProxyHash != inst.proxy.hash {
+		return r.applyProxy(req, cfg)
+	}
+
+	// objects deleted or edited on the cluster are restored by applying them
+	// again. The installed objects are read at most once per driftInterval,
+	// as the reconciles of the owned Deployments end up here
+	if wait := inst.driftChecked.Add(driftInterval).Sub(timeNow()); wait > 0 {
+		return reconcile.Result{Requeue: true, RequeueAfter: wait}, nil
+	}
+	inst.driftChecked = timeNow()
+	log := requestLogger(req, "validate-version")
+	missing, edited, restore, err := r.drift(cfg)
+	if err != nil {
+		log.Error(err, "failed to check the inventory")
+		return reconcile.Result{}, err
+	}
+	if len(missing) > 0 || len(edited) > 0 {
+		log.Info("installed objects drifted", "missing", missing, "edited", edited)
+		// the objects applied before the operator restarted are only known
+		// to the inventory, they are restored by installing again
+		if len(restore.Resources()) == 0 {
+			return r.applyPipeline(req, cfg)
+		}
+		if err := applyInPlace(restore); err != nil {
+			log.Error(err, "failed to restore the drifted objects")
+			return reconcile.Result{}, err
+		}
+		log.Info("successfully restored the drifted objects")
+	}
 	// NOTE: do not requeue
 	return reconcile.Result{}, nil
 }
@@ -374,54 +588,65 @@ func matchesUUID(target string) bool {
 
 func (r *ReconcileConfig) applyTriggers(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
 	log := requestLogger(req, "apply-triggers")
+	inst := r.instanceFor(cfg.Name)
 
-	triggerImages := transform.ToLowerCaseKeys(imagesFromEnv(transform.TriggersImagePrefix))
-	newTriggers, err := transformManifest(cfg, &r.triggers, transform.DeploymentImages(triggerImages))
+	newTriggers, err := r.triggersManifest(cfg)
 	if err != nil {
 		log.Error(err, "failed to apply manifest transformations on triggers")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.TriggersError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{}, err
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, err)
 	}
-	r.triggers = newTriggers
+	if err := clusterConflicts(cfg, newTriggers); err != nil {
+		log.Error(err, "trigger resources conflict with another instance")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.TriggersError,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, err)
+	}
+	inst.triggers = newTriggers
 
-	if err := r.triggers.Filter(mf.Not(recreateResource)).Apply(); err != nil {
+	if err := inst.triggers.Filter(mf.Not(recreateResource)).Apply(); err != nil {
 		log.Error(err, "failed to apply non deployment and service trigger manifest")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.TriggersError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{}, fmt.Errorf("failed to apply non deployment and service trigger manifest: %w", err)
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to apply non deployment and service trigger manifest: %w", err))
 	}
-	if err := r.triggers.Filter(recreateResource).Apply(); err != nil {
+	if err := inst.triggers.Filter(recreateResource).Apply(); err != nil {
 		if errors.IsInvalid(err) {
-			if err := r.deleteAndCreateTriggers(); err != nil {
-				_ = r.updateStatus(cfg, op.ConfigCondition{
+			if err := deleteAndCreate(inst.triggers); err != nil {
+				return r.retryOrFail(cfg, op.ConfigCondition{
 					Code:            op.TriggersError,
-					Details:         err.Error(),
-					PipelineVersion: pipelineVersion,
-					Version:         flag.TektonVersion})
-				return reconcile.Result{}, fmt.Errorf("failed to recreate trigger deployments and services: %w", err)
+					PipelineVersion: inst.pipelineVersion,
+					Version:         flag.TektonVersion}, fmt.Errorf("failed to recreate trigger deployments and services: %w", err))
 			}
 		} else {
-			_ = r.updateStatus(cfg, op.ConfigCondition{
+			return r.retryOrFail(cfg, op.ConfigCondition{
 				Code:            op.TriggersError,
-				Details:         err.Error(),
-				PipelineVersion: pipelineVersion,
-				Version:         flag.TektonVersion})
-			return reconcile.Result{}, fmt.Errorf("failed to apply trigger deployments and services: %w", err)
+				PipelineVersion: inst.pipelineVersion,
+				Version:         flag.TektonVersion}, fmt.Errorf("failed to apply trigger deployments and services: %w", err))
 		}
 	}
+	if err := r.prune(cfg, componentTriggers, inst.triggers); err != nil {
+		log.Error(err, "failed to prune trigger resources")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.TriggersError,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to prune trigger resources: %w", err))
+	}
+	if err := r.recordInventory(cfg, componentTriggers, inst.triggers); err != nil {
+		log.Error(err, "failed to record the triggers inventory")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.TriggersError,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to record the triggers inventory: %w", err))
+	}
 	log.Info("successfully applied all trigger resources")
 	err = r.updateStatus(cfg, op.ConfigCondition{
 		Code:            op.AppliedTriggers,
-		PipelineVersion: pipelineVersion,
+		PipelineVersion: inst.pipelineVersion,
 		Version:         flag.TektonVersion,
 	})
 	return reconcile.Result{Requeue: true}, err
@@ -429,74 +654,117 @@ func (r *ReconcileConfig) applyTriggers(req reconcile.Request, cfg *op.Config) (
 
 func (r *ReconcileConfig) applyAddons(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
 	log := requestLogger(req, "apply-addons")
+	inst := r.instanceFor(cfg.Name)
+
+	if !ownsAddons(cfg) {
+		log.Info("skipping cluster scoped addons", "owner", flag.ResourceWatched)
+		err := r.updateStatus(cfg, op.ConfigCondition{
+			Code:            op.AppliedAddons,
+			Details:         "addons are installed by config " + flag.ResourceWatched,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion,
+		})
+		return reconcile.Result{Requeue: true}, err
+	}
+
+	tknDownloadURL, err := r.applyCLIDownloads(cfg)
+	if err != nil {
+		log.Error(err, "failed to apply the tkn download server")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.AddonsError,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to apply the tkn download server: %w", err))
+	}
 
 	//add TaskProviderType label to ClusterTasks (community, redhat, certified)
 	addonImages := transform.ToLowerCaseKeys(imagesFromEnv(transform.AddonsImagePrefix))
 	addnTfrms := []mf.Transformer{
 		transform.InjectLabel(flag.LabelProviderType, flag.ProviderTypeRedHat, transform.Overwrite, "ClusterTask"),
 		transform.TaskImages(addonImages),
+		paddons.TknDownloadLinks(inst.pipelineVersion, tknDownloadURL),
+	}
+	addons, err := r.withTaskSnippets(r.addons)
+	if err != nil {
+		log.Error(err, "failed to generate the snippets of the cluster tasks")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.AddonsError,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, permanentError{err})
 	}
-	newAddons, err := transformManifest(cfg, &r.addons, addnTfrms...)
+	newAddons, err := transformManifest(cfg, &addons, componentAddons, addnTfrms...)
 	if err != nil {
 		log.Error(err, "failed to apply manifest transformations on addons")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.AddonsError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			TriggersVersion: triggersVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{}, err
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, err)
 	}
-	r.addons = newAddons
+	inst.addons = newAddons
 
-	if err := deleteAndCreateAddons(r.addons); err != nil {
+	if err := applyInPlace(inst.addons); err != nil {
 		log.Error(err, "failed to apply addons yaml manifest")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.AddonsError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			TriggersVersion: triggersVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{}, fmt.Errorf("failed to apply addons yaml manifest: %w", err)
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to apply addons yaml manifest: %w", err))
 	}
 
+	if err := r.prune(cfg, componentAddons, inst.addons); err != nil {
+		log.Error(err, "failed to prune addon resources")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.AddonsError,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to prune addon resources: %w", err))
+	}
+	if err := r.recordInventory(cfg, componentAddons, inst.addons); err != nil {
+		log.Error(err, "failed to record the addons inventory")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.AddonsError,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to record the addons inventory: %w", err))
+	}
 	log.Info("successfully applied all addon resources")
 
 	err = r.updateStatus(cfg, op.ConfigCondition{
 		Code:            op.AppliedAddons,
-		PipelineVersion: pipelineVersion,
-		TriggersVersion: triggersVersion,
+		PipelineVersion: inst.pipelineVersion,
+		TriggersVersion: inst.triggersVersion,
 		Version:         flag.TektonVersion,
 	})
 	return reconcile.Result{Requeue: true}, err
 }
 
-func (r *ReconcileConfig) deleteAndCreateTriggers() error {
-	timeout := time.Duration(replaceTimeout) * time.Second
-
-	propPolicy := mf.PropagationPolicy(metav1.DeletePropagationForeground)
-	if err := r.triggers.Filter(recreateResource).Delete(propPolicy); err != nil {
-		log.Error(err, "failed to delete triggers deployment and service resources")
-		return err
-	}
-
-	if err := wait.PollImmediate(1*time.Second, timeout, func() (bool, error) {
-		for _, deploy := range r.triggers.Filter(recreateResource).Resources() {
-			if _, err := r.triggers.Client.Get(&deploy); !apierrors.IsNotFound(err) {
-				return false, err
+// applyInPlace applies the addon resources one by one, so that the cluster
+// tasks and the other objects in use by the users are updated in place. Only
+// an object whose update is rejected as invalid, e.g. because a field is
+// immutable, is deleted and created again
+func applyInPlace(manifest mf.Manifest) error {
+	for _, res := range manifest.Resources() {
+		one := manifest.Filter(sameObject(res))
+		err := one.Apply()
+		if !errors.IsInvalid(err) {
+			if err != nil {
+				return err
 			}
+			continue
+		}
+		if err := recreate(one); err != nil {
+			return err
 		}
-		return true, nil
-	}); err != nil {
-		return err
 	}
-
-	return r.triggers.Filter(recreateResource).Apply()
+	return nil
 }
 
-func deleteAndCreateAddons(manifest mf.Manifest) error {
+// recreate deletes the resources of manifest, waits until they are gone and
+// creates them again
+func recreate(manifest mf.Manifest) error {
 	timeout := time.Duration(replaceTimeoutAddons) * time.Second
 
 	if err := manifest.Delete(); err != nil {
@@ -505,8 +773,8 @@ func deleteAndCreateAddons(manifest mf.Manifest) error {
 	}
 
 	if err := wait.PollImmediate(1*time.Second, timeout, func() (bool, error) {
-		for _, deploy := range manifest.Resources() {
-			if _, err := manifest.Client.Get(&deploy); !apierrors.IsNotFound(err) {
+		for _, res := range manifest.Resources() {
+			if _, err := manifest.Client.Get(&res); !apierrors.IsNotFound(err) {
 				return false, err
 			}
 		}
@@ -518,14 +786,55 @@ func deleteAndCreateAddons(manifest mf.Manifest) error {
 	return manifest.Apply()
 }
 
+// sameObject matches the resources with the kind, namespace and name of obj
+func sameObject(obj unstructured.Unstructured) mf.Predicate {
+	return func(u *unstructured.Unstructured) bool {
+		return u.GroupVersionKind() == obj.GroupVersionKind() &&
+			u.GetNamespace() == obj.GetNamespace() &&
+			u.GetName() == obj.GetName()
+	}
+}
+
+// unknownDeployments returns an error naming the deployment overrides that do
+// not match a Deployment of the component manifest
+func unknownDeployments(component string, m mf.Manifest, overrides map[string]op.DeploymentOverride) error {
+	var unknown []string
+	for name := range overrides {
+		if len(m.Filter(mf.ByKind("Deployment"), mf.ByName(name)).Resources()) == 0 {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("spec.%s.deployments: unknown deployments %s", component, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
 // this will give the component version from the respective controller label
 func getComponentVersion(manifest mf.Manifest, controllerName string, labelName string) string {
-	labels := manifest.Filter(mf.ByKind("Deployment"), mf.ByName(controllerName)).Resources()[0].GetLabels()
-	return labels[labelName]
+	controllers := manifest.Filter(mf.ByKind("Deployment"), mf.ByName(controllerName)).Resources()
+	if len(controllers) == 0 {
+		return ""
+	}
+	return controllers[0].GetLabels()[labelName]
 }
 
 func (r *ReconcileConfig) applyCommunityResources(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
 	log := requestLogger(req, "apply-non-redhat-resources")
+	inst := r.instanceFor(cfg.Name)
+
+	if !ownsAddons(cfg) {
+		log.Info("skipping cluster scoped community resources", "owner", flag.ResourceWatched)
+		cfg.Status.UnmatchedOverlays = unmatchedOverlays(cfg, inst.pipeline, inst.triggers)
+		err := r.updateStatus(cfg, op.ConfigCondition{
+			Code:            op.InstalledStatus,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion,
+		})
+		return reconcile.Result{Requeue: true}, err
+	}
 
 	//add TaskProviderType label to ClusterTasks (community, redhat, certified)
 	addonImages := transform.ToLowerCaseKeys(imagesFromEnv(transform.AddonsImagePrefix))
@@ -535,67 +844,131 @@ func (r *ReconcileConfig) applyCommunityResources(req reconcile.Request, cfg *op
 		transform.InjectLabel(flag.LabelProviderType, flag.ProviderTypeCommunity, transform.Overwrite),
 		transform.TaskImages(addonImages),
 	}
-	newCommunityResources, err := transformManifest(cfg, &r.community, addnTfrms...)
+	community, err := r.withTaskSnippets(r.community)
+	if err != nil {
+		log.Error(err, "failed to generate the snippets of the community tasks")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.CommunityResourcesError,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, permanentError{err})
+	}
+	newCommunityResources, err := transformManifest(cfg, &community, componentCommunity, addnTfrms...)
 	if err != nil {
 		log.Error(err, "failed to apply manifest transformations on pipeline-addons")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.CommunityResourcesError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			TriggersVersion: triggersVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{}, err
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, err)
 	}
-	r.community = newCommunityResources
+	inst.community = newCommunityResources
 
-	if err := deleteAndCreateAddons(r.community); err != nil {
+	if err := applyInPlace(inst.community); err != nil {
 		log.Error(err, "failed to apply non Red Hat resources yaml manifest")
-		// ignoring failure to update
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.CommunityResourcesError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			TriggersVersion: triggersVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{}, err
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, err)
+	}
+	// the community resources are all left out with --skip-non-redhat
+	if err := r.prune(cfg, componentCommunity, inst.community, clusterTaskGVK, consoleYAMLSampleGVK); err != nil {
+		log.Error(err, "failed to prune non Red Hat resources")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.CommunityResourcesError,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to prune non Red Hat resources: %w", err))
+	}
+	if err := r.recordInventory(cfg, componentCommunity, inst.community); err != nil {
+		log.Error(err, "failed to record the non Red Hat resources inventory")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.CommunityResourcesError,
+			PipelineVersion: inst.pipelineVersion,
+			TriggersVersion: inst.triggersVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to record the non Red Hat resources inventory: %w", err))
 	}
 	log.Info("successfully applied all non Red Hat resources")
 
+	cfg.Status.UnmatchedOverlays = unmatchedOverlays(cfg, inst.pipeline, inst.triggers, inst.addons, inst.community, inst.cliDownloads)
+
 	err = r.updateStatus(cfg, op.ConfigCondition{
 		Code:            op.InstalledStatus,
-		PipelineVersion: pipelineVersion,
-		TriggersVersion: triggersVersion,
+		PipelineVersion: inst.pipelineVersion,
+		TriggersVersion: inst.triggersVersion,
 		Version:         flag.TektonVersion,
 	})
 	return reconcile.Result{Requeue: true}, err
 }
 
-func transformManifest(cfg *op.Config, m *mf.Manifest, addnTfrms ...mf.Transformer) (mf.Manifest, error) {
+// pipelineManifest returns the pipeline release selected for cfg with the
+// changes of the operator and of the spec
+func (r *ReconcileConfig) pipelineManifest(cfg *op.Config) (mf.Manifest, error) {
+	inst := r.instanceFor(cfg.Name)
+	images := transform.ToLowerCaseKeys(imagesFromEnv(transform.PipelinesImagePrefix))
+	release, err := withHighAvailability(cfg, inst.pipelineRelease, pipelineHADeployments)
+	if err != nil {
+		return release, err
+	}
+	release, err = withTrustedCA(release, inst.proxy)
+	if err != nil {
+		return release, err
+	}
+	tfs := append([]mf.Transformer{transform.DeploymentImages(images)}, haTransformers(cfg, pipelineHADeployments)...)
+	tfs = append(tfs, proxyTransformers(inst.proxy)...)
+	tfs = append(tfs, transform.DeploymentOverrides(cfg.Spec.Pipeline.Deployments))
+	return transformManifest(cfg, &release, componentPipeline, tfs...)
+}
+
+// triggersManifest returns the triggers release selected for cfg with the
+// changes of the operator and of the spec
+func (r *ReconcileConfig) triggersManifest(cfg *op.Config) (mf.Manifest, error) {
+	inst := r.instanceFor(cfg.Name)
+	images := transform.ToLowerCaseKeys(imagesFromEnv(transform.TriggersImagePrefix))
+	release, err := withHighAvailability(cfg, inst.triggersRelease, triggersHADeployments)
+	if err != nil {
+		return release, err
+	}
+	tfs := append([]mf.Transformer{transform.DeploymentImages(images)}, haTransformers(cfg, triggersHADeployments)...)
+	tfs = append(tfs, proxyTransformers(inst.proxy)...)
+	tfs = append(tfs, transform.DeploymentOverrides(cfg.Spec.Triggers.Deployments))
+	return transformManifest(cfg, &release, componentTriggers, tfs...)
+}
+
+func transformManifest(cfg *op.Config, m *mf.Manifest, component string, addnTfrms ...mf.Transformer) (mf.Manifest, error) {
 	rbManifest := m.Filter(roleBinding)
 	rest := m.Filter(mf.Not(roleBinding))
+	labels := installLabels(cfg, component)
 	tfs := []mf.Transformer{
 		mf.InjectOwner(cfg),
+		transform.InjectLabel(flag.LabelInstance, cfg.Name, transform.Overwrite),
 		transform.InjectNamespaceConditional(flag.AnnotationPreserveNS, cfg.Spec.TargetNamespace),
 		transform.InjectNamespaceCRDWebhookClientConfig(cfg.Spec.TargetNamespace),
 		transform.InjectDefaultSA(flag.DefaultSA),
 		transform.SetDisableAffinityAssistant(flag.DefaultDisableAffinityAssistant),
 	}
 
+	tfs = append(tfs, labels...)
 	tfs = append(tfs, addnTfrms...)
+	// the overlays of the admin come last, so they patch the final objects
+	tfs = append(tfs, transform.Overlays(cfg.Spec.Overlays))
 	rest, err := rest.Transform(tfs...)
 	if err != nil {
-		return *m, err
+		return *m, permanentError{err}
 	}
 
 	tfs = []mf.Transformer{
 		mf.InjectOwner(cfg),
+		transform.InjectLabel(flag.LabelInstance, cfg.Name, transform.Overwrite),
 		transform.InjectNamespaceRoleBindingConditional(flag.AnnotationPreserveNS,
 			flag.AnnotationPreserveRBSubjectNS, cfg.Spec.TargetNamespace),
 	}
+	tfs = append(tfs, labels...)
+	tfs = append(tfs, transform.Overlays(cfg.Spec.Overlays))
 	rbManifest, err = rbManifest.Transform(tfs...)
 	if err != nil {
-		return *m, err
+		return *m, permanentError{err}
 	}
 	return rest.Append(rbManifest), nil
 }
@@ -603,37 +976,55 @@ func transformManifest(cfg *op.Config, m *mf.Manifest, addnTfrms ...mf.Transform
 func (r *ReconcileConfig) validatePipeline(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
 	log := requestLogger(req, "validate-pipeline")
 	log.Info("validating pipelines")
+	inst := r.instanceFor(cfg.Name)
 
 	running, err := r.validateDeployments(req, cfg, flag.PipelineControllerName, flag.PipelineWebhookName)
 	if err != nil {
 		log.Error(err, "failed to validate pipeline controller deployments")
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:    op.PipelineValidateError,
-			Details: err.Error(),
-			Version: flag.TektonVersion})
-		return reconcile.Result{}, err
+			Version: flag.TektonVersion}, err)
 	}
 
 	if !running {
-		return reconcile.Result{Requeue: true, RequeueAfter: 15 * time.Second}, nil
+		return r.waitOrFail(cfg, op.ConfigCondition{
+			Code:    op.AppliedPipeline,
+			Details: r.deploymentsNotReady(cfg, flag.PipelineControllerName, flag.PipelineWebhookName),
+			Version: flag.TektonVersion})
 	}
 
 	found, err := validate.Webhook(context.TODO(), r.client, flag.PipelineWebhookConfiguration)
 	if err != nil {
 		log.Error(err, "failed to validate mutating webhook")
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:    op.PipelineValidateError,
-			Details: err.Error(),
-			Version: flag.TektonVersion})
-		return reconcile.Result{RequeueAfter: 15 * time.Second}, err
+			Version: flag.TektonVersion}, err)
 	}
 	if !found {
-		return reconcile.Result{Requeue: true, RequeueAfter: 15 * time.Second}, nil
+		return r.waitOrFail(cfg, op.ConfigCondition{
+			Code:    op.AppliedPipeline,
+			Details: "waiting for mutating webhook configuration " + flag.PipelineWebhookConfiguration,
+			Version: flag.TektonVersion})
+	}
+
+	ready, err := r.validateWebhook(req, inst.pipeline, flag.PipelineWebhookName, cfg.Spec.TargetNamespace,
+		pipelineProbe(cfg.Spec.TargetNamespace))
+	if err != nil {
+		log.Error(err, "failed to validate pipeline webhook")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:    op.PipelineValidateError,
+			Version: flag.TektonVersion}, err)
+	}
+	if !ready {
+		return r.waitOrFail(cfg, op.ConfigCondition{
+			Code:    op.AppliedPipeline,
+			Details: "waiting for " + flag.PipelineWebhookName + " to serve admission requests",
+			Version: flag.TektonVersion})
 	}
 
 	err = r.updateStatus(cfg, op.ConfigCondition{
 		Code:            op.ValidatedPipeline,
-		PipelineVersion: pipelineVersion,
+		PipelineVersion: inst.pipelineVersion,
 		Version:         flag.TektonVersion,
 	})
 	if err != nil {
@@ -647,40 +1038,62 @@ func (r *ReconcileConfig) validatePipeline(req reconcile.Request, cfg *op.Config
 func (r *ReconcileConfig) validateTriggers(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
 	log := requestLogger(req, "validate-triggers")
 	log.Info("validating triggers")
+	inst := r.instanceFor(cfg.Name)
 
 	running, err := r.validateDeployments(req, cfg, flag.TriggerControllerName, flag.TriggerWebhookName)
 	if err != nil {
 		log.Error(err, "failed to validate triggers controller deployments")
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.TriggersValidateError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{}, err
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, err)
 	}
 
 	if !running {
-		return reconcile.Result{Requeue: true, RequeueAfter: 15 * time.Second}, nil
+		return r.waitOrFail(cfg, op.ConfigCondition{
+			Code:            op.AppliedTriggers,
+			Details:         r.deploymentsNotReady(cfg, flag.TriggerControllerName, flag.TriggerWebhookName),
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion})
 	}
 
 	found, err := validate.Webhook(context.TODO(), r.client, flag.TriggerWebhookConfiguration)
 	if err != nil {
 		log.Error(err, "failed to validate mutating webhook")
-		_ = r.updateStatus(cfg, op.ConfigCondition{
+		return r.retryOrFail(cfg, op.ConfigCondition{
 			Code:            op.TriggersValidateError,
-			Details:         err.Error(),
-			PipelineVersion: pipelineVersion,
-			Version:         flag.TektonVersion})
-		return reconcile.Result{RequeueAfter: 15 * time.Second}, err
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, err)
 	}
 	if !found {
-		return reconcile.Result{Requeue: true, RequeueAfter: 15 * time.Second}, nil
+		return r.waitOrFail(cfg, op.ConfigCondition{
+			Code:            op.AppliedTriggers,
+			Details:         "waiting for mutating webhook configuration " + flag.TriggerWebhookConfiguration,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion})
+	}
+
+	ready, err := r.validateWebhook(req, inst.triggers, flag.TriggerWebhookName, cfg.Spec.TargetNamespace,
+		triggersProbe(cfg.Spec.TargetNamespace))
+	if err != nil {
+		log.Error(err, "failed to validate triggers webhook")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.TriggersValidateError,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, err)
+	}
+	if !ready {
+		return r.waitOrFail(cfg, op.ConfigCondition{
+			Code:            op.AppliedTriggers,
+			Details:         "waiting for " + flag.TriggerWebhookName + " to serve admission requests",
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion})
 	}
 
 	err = r.updateStatus(cfg, op.ConfigCondition{
 		Code:            op.ValidatedTriggers,
-		PipelineVersion: pipelineVersion,
-		TriggersVersion: triggersVersion,
+		PipelineVersion: inst.pipelineVersion,
+		TriggersVersion: inst.triggersVersion,
 		Version:         flag.TektonVersion,
 	})
 	if err != nil {
@@ -702,7 +1115,7 @@ func (r *ReconcileConfig) validateDeployments(req reconcile.Request, cfg *op.Con
 	)
 	if err != nil {
 		log.Error(err, "validating controller deployment error")
-		return false, err
+		return false, withPodFailures(err, r.podFailures(cfg, controllerName))
 	}
 
 	log.Info("validating webhook")
@@ -713,7 +1126,7 @@ func (r *ReconcileConfig) validateDeployments(req reconcile.Request, cfg *op.Con
 	)
 	if err != nil {
 		log.Error(err, "validating webhook deployment error")
-		return false, err
+		return false, withPodFailures(err, r.podFailures(cfg, webhookName))
 	}
 
 	if !controller || !webhook {
@@ -723,6 +1136,125 @@ func (r *ReconcileConfig) validateDeployments(req reconcile.Request, cfg *op.Con
 	return controller && webhook, nil
 }
 
+// podFailures returns a summary of the pod level failures, e.g. ImagePullBackOff,
+// of the given deployments in the target namespace
+func (r *ReconcileConfig) podFailures(cfg *op.Config, deployments ...string) string {
+	var failures []string
+	for _, name := range deployments {
+		reasons, err := validate.PodFailures(context.TODO(), r.reader(), name, cfg.Spec.TargetNamespace)
+		if err != nil {
+			ctrlLog.Error(err, "failed to find pod failures", "deployment", name)
+			continue
+		}
+		for _, reason := range reasons {
+			failures = append(failures, name+": "+reason)
+		}
+	}
+	return strings.Join(failures, "; ")
+}
+
+// deploymentsNotReady describes why the given deployments are not yet ready
+func (r *ReconcileConfig) deploymentsNotReady(cfg *op.Config, deployments ...string) string {
+	if failures := r.podFailures(cfg, deployments...); failures != "" {
+		return failures
+	}
+
+	var replicas []string
+	for _, name := range deployments {
+		ready, desired, err := validate.DeploymentReplicas(context.TODO(), r.client, name, cfg.Spec.TargetNamespace)
+		if err != nil {
+			ctrlLog.Error(err, "failed to read deployment replicas", "deployment", name)
+			replicas = append(replicas, name)
+			continue
+		}
+		replicas = append(replicas, fmt.Sprintf("%s (%d/%d ready)", name, ready, desired))
+	}
+	return "waiting for deployments " + strings.Join(replicas, ", ") + " to be ready"
+}
+
+func withPodFailures(err error, failures string) error {
+	if failures == "" {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, failures)
+}
+
+// validateWebhook confirms that the webhook service has ready endpoints, that
+// every CRD in the manifest is established and that the admission webhooks
+// respond to a dry-run create of probe
+func (r *ReconcileConfig) validateWebhook(req reconcile.Request, m mf.Manifest, serviceName, namespace string, probe *unstructured.Unstructured) (bool, error) {
+	log := requestLogger(req, "validate").WithName("webhook")
+
+	log.Info("validating webhook endpoints", "service", serviceName)
+	endpoints, err := validate.Endpoints(context.TODO(), r.reader(), serviceName, namespace)
+	if err != nil {
+		log.Error(err, "validating webhook endpoints error")
+		return false, err
+	}
+	if !endpoints {
+		log.Info("webhook service has no ready endpoints yet", "service", serviceName)
+		return false, nil
+	}
+
+	log.Info("validating crds")
+	for _, crd := range m.Filter(mf.ByKind("CustomResourceDefinition")).Resources() {
+		established, err := validate.CRDEstablished(context.TODO(), r.client, crd.GetName())
+		if err != nil {
+			log.Error(err, "validating crd error", "crd", crd.GetName())
+			return false, err
+		}
+		if !established {
+			log.Info("crd not yet established", "crd", crd.GetName())
+			return false, nil
+		}
+	}
+
+	log.Info("validating webhook reachability", "kind", probe.GetKind())
+	reachable, err := validate.WebhookReachable(context.TODO(), r.client, probe)
+	if err != nil {
+		log.Error(err, "validating webhook reachability error")
+		return false, err
+	}
+	if !reachable {
+		log.Info("webhook not yet responding to admission requests", "kind", probe.GetKind())
+	}
+	return reachable, nil
+}
+
+// pipelineProbe returns a minimal Task used to dry-run the pipeline webhooks
+func pipelineProbe(namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "tekton.dev/v1beta1",
+		"kind":       "Task",
+		"metadata": map[string]interface{}{
+			"name":      flag.WebhookProbeName,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"steps": []interface{}{
+				map[string]interface{}{"name": "probe", "image": "busybox", "script": "exit 0"},
+			},
+		},
+	}}
+}
+
+// triggersProbe returns a minimal TriggerBinding used to dry-run the triggers webhooks
+func triggersProbe(namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "triggers.tekton.dev/v1alpha1",
+		"kind":       "TriggerBinding",
+		"metadata": map[string]interface{}{
+			"name":      flag.WebhookProbeName,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"params": []interface{}{
+				map[string]interface{}{"name": "probe", "value": "probe"},
+			},
+		},
+	}}
+}
+
 func (r *ReconcileConfig) reconcileDeletion(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
 	log := requestLogger(req, "delete")
 
@@ -730,40 +1262,228 @@ func (r *ReconcileConfig) reconcileDeletion(req reconcile.Request, cfg *op.Confi
 
 	// Requested object not found, could have been deleted after reconcile request.
 	// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+	if err := r.deleteComponents(req, mf.Nothing); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.forgetInstance(req.Name)
+
+	// Return and don't requeue
+	return reconcile.Result{}, nil
+}
+
+// deleteComponents deletes the components installed for the config named
+// req.Name and the objects of its inventory, except the ones matched by keep
+func (r *ReconcileConfig) deleteComponents(req reconcile.Request, keep mf.Predicate) error {
+	log := requestLogger(req, "delete")
+
 	propPolicy := mf.PropagationPolicy(metav1.DeletePropagationForeground)
 
-	if err := r.pipeline.Delete(propPolicy); err != nil {
+	inst := r.instanceFor(req.Name)
+	pipeline, triggers, addons, cliDownloads := inst.pipeline, inst.triggers, inst.addons, inst.cliDownloads
+	if req.Name != flag.ResourceWatched {
+		// other instances never own the addons and may be refused the
+		// cluster scoped resources, so only delete what they installed
+		pipeline = pipeline.Filter(ownedBy(pipeline, req.Name))
+		triggers = triggers.Filter(ownedBy(triggers, req.Name))
+		addons = mf.Manifest{}
+		cliDownloads = mf.Manifest{}
+	}
+	pipeline, triggers = pipeline.Filter(mf.Not(keep)), triggers.Filter(mf.Not(keep))
+	addons, cliDownloads = addons.Filter(mf.Not(keep)), cliDownloads.Filter(mf.Not(keep))
+
+	if err := pipeline.Delete(propPolicy); err != nil {
 		log.Error(err, "failed to delete pipeline core")
-		return reconcile.Result{}, err
+		return err
 	}
 
-	if err := r.triggers.Delete(propPolicy); err != nil {
+	if err := triggers.Delete(propPolicy); err != nil {
 		log.Error(err, "failed to delete triggers")
-		return reconcile.Result{}, err
+		return err
 	}
 
-	if err := r.addons.Delete(propPolicy); err != nil {
+	if err := addons.Delete(propPolicy); err != nil {
 		log.Error(err, "failed to delete pipeline addons")
-		return reconcile.Result{}, err
+		return err
 	}
 
-	// Return and don't requeue
-	return reconcile.Result{}, nil
+	if err := cliDownloads.Delete(propPolicy); err != nil {
+		log.Error(err, "failed to delete the tkn download server")
+		return err
+	}
+
+	if err := r.deleteInventory(req.Name, keep); err != nil {
+		log.Error(err, "failed to delete the objects of the inventory")
+		return err
+	}
+
+	return nil
 }
 
 // markInvalidResource sets the status of resourse as invalid
-func (r *ReconcileConfig) markInvalidResource(cfg *op.Config) {
+func (r *ReconcileConfig) markInvalidResource(cfg *op.Config, details string) {
 	err := r.updateStatus(cfg,
 		op.ConfigCondition{
 			Code:    op.InvalidResource,
-			Details: "metadata.name must be " + flag.ResourceWatched,
+			Details: details,
 			Version: "unknown"})
 	if err != nil {
 		ctrlLog.Info("failed to update status as invalid")
 	}
 }
 
-// updateStatus set the status of cfg to s and refreshes cfg to the lastest version
+// retryOrFail records the error of the current phase in the status of cfg and
+// requeues with an exponential backoff. Errors that will not go away by
+// retrying and phases that exceed their deadline mark the install as failed
+func (r *ReconcileConfig) retryOrFail(cfg *op.Config, c op.ConfigCondition, err error) (reconcile.Result, error) {
+	c.Details = err.Error()
+	if isPermanent(err) {
+		return r.markFailed(cfg, c)
+	}
+	return r.waitOrFail(cfg, c)
+}
+
+// waitOrFail records c in the status of cfg and requeues with an exponential
+// backoff until the phase of c exceeds its deadline
+func (r *ReconcileConfig) waitOrFail(cfg *op.Config, c op.ConfigCondition) (reconcile.Result, error) {
+	if time.Since(phaseStart(cfg, c.Code)) > flag.PhaseDeadline {
+		c.Details = fmt.Sprintf("phase %s did not complete within %s: %s", phases[c.Code], flag.PhaseDeadline, c.Details)
+		return r.markFailed(cfg, c)
+	}
+
+	if err := r.updateStatus(cfg, c); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: backoff(cfg.Status.Conditions[0].Attempts)}, nil
+}
+
+// markFailed records c followed by the terminal FailedStatus in the status of cfg
+func (r *ReconcileConfig) markFailed(cfg *op.Config, c op.ConfigCondition) (reconcile.Result, error) {
+	ctrlLog.Info("install failed", "code", c.Code, "details", c.Details)
+
+	if err := r.updateStatus(cfg, c); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	failed := c
+	failed.Code = op.FailedStatus
+	// NOTE: do not requeue; a failed install is retried on spec change or
+	// when the retry annotation is set
+	return reconcile.Result{}, r.updateStatus(cfg, failed)
+}
+
+// retryFailed resumes a failed install at the phase that failed once the spec
+// of cfg has changed or the retry annotation has been set
+func (r *ReconcileConfig) retryFailed(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
+	log := requestLogger(req, "retry")
+
+	failed := cfg.Status.Conditions[0]
+	_, retry := cfg.Annotations[flag.AnnotationRetry]
+	if !retry && failed.ObservedGeneration == cfg.Generation {
+		log.Info("install failed, waiting for spec change or annotation "+flag.AnnotationRetry, "details", failed.Details)
+		return reconcile.Result{}, nil
+	}
+
+	if retry {
+		delete(cfg.Annotations, flag.AnnotationRetry)
+		if err := r.client.Update(context.TODO(), cfg); err != nil {
+			log.Error(err, "failed to remove retry annotation")
+			return reconcile.Result{}, err
+		}
+	}
+
+	resume := op.EmptyStatus
+	if len(cfg.Status.Conditions) > 1 {
+		resume = cfg.Status.Conditions[1].Code
+	}
+	log.Info("retrying failed install", "code", resume)
+
+	err := r.updateStatus(cfg, op.ConfigCondition{
+		Code:            resume,
+		Details:         "retrying after failure",
+		PipelineVersion: failed.PipelineVersion,
+		TriggersVersion: failed.TriggersVersion,
+		Version:         flag.TektonVersion,
+	})
+	return reconcile.Result{Requeue: true}, err
+}
+
+// reconcileRequested resets the install to its first phase so that every
+// component is re-applied and re-validated without deleting anything, and
+// records requested as handled
+func (r *ReconcileConfig) reconcileRequested(req reconcile.Request, cfg *op.Config, requested string) (reconcile.Result, error) {
+	log := requestLogger(req, "reconcile-request")
+	log.Info("re-applying all components", "request", requested)
+
+	cfg.Status.LastReconcileRequest = requested
+	err := r.updateStatus(cfg, op.ConfigCondition{
+		Code:    op.EmptyStatus,
+		Details: "reconcile requested at " + requested,
+		Version: flag.TektonVersion,
+	})
+	return reconcile.Result{Requeue: true}, err
+}
+
+// phaseStart returns the time at which cfg entered the install phase of code
+func phaseStart(cfg *op.Config, code op.InstallStatus) time.Time {
+	start := time.Now()
+	phase, ok := phases[code]
+	if !ok {
+		return start
+	}
+	for _, c := range cfg.Status.Conditions {
+		if phases[c.Code] != phase {
+			break
+		}
+		if !c.LastTransitionTime.IsZero() {
+			start = c.LastTransitionTime.Time
+		}
+	}
+	return start
+}
+
+// backoff returns the delay before the next attempt, doubling from
+// retryBaseDelay up to retryMaxDelay
+func backoff(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for i := int32(1); i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return delay
+}
+
+// permanentError marks errors that will not go away by retrying, e.g. a
+// manifest that cannot be transformed
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+func isPermanent(err error) bool {
+	if goerrors.As(err, &permanentError{}) {
+		return true
+	}
+
+	var status apierrors.APIStatus
+	if !goerrors.As(err, &status) {
+		return false
+	}
+	switch status.Status().Reason {
+	case metav1.StatusReasonBadRequest, metav1.StatusReasonInvalid, metav1.StatusReasonMethodNotAllowed:
+		return true
+	}
+	return false
+}
+
+// updateStatus records c in the status of cfg and refreshes cfg to the lastest
+// version. A condition with the same code as the latest one replaces it and
+// counts as another attempt of the same phase
 func (r *ReconcileConfig) updateStatus(cfg *op.Config, c op.ConfigCondition) error {
 
 	// NOTE: need to use a deepcopy since Status().Update() seems to reset the
@@ -772,7 +1492,16 @@ func (r *ReconcileConfig) updateStatus(cfg *op.Config, c op.ConfigCondition) err
 
 	tmp := cfg.DeepCopy()
 	tmp.Status.OperatorUUID = flag.OperatorUUID
-	tmp.Status.Conditions = append([]op.ConfigCondition{c}, tmp.Status.Conditions...)
+	c.ObservedGeneration = cfg.Generation
+	if con := tmp.Status.Conditions; len(con) > 0 && con[0].Code == c.Code {
+		c.Attempts = con[0].Attempts + 1
+		c.LastTransitionTime = con[0].LastTransitionTime
+		con[0] = c
+	} else {
+		c.Attempts = 1
+		c.LastTransitionTime = metav1.Now()
+		tmp.Status.Conditions = append([]op.ConfigCondition{c}, con...)
+	}
 
 	if err := r.client.Status().Update(context.TODO(), tmp); err != nil {
 		log.Error(err, "status update failed")
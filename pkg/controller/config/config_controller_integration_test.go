@@ -0,0 +1,186 @@
+//go:build integration
+// +build integration
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/test/integration"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestIntegrationInstall(t *testing.T) {
+	env := integration.Start(t)
+	defer env.Stop(t)
+
+	r := newIntegrationReconciler(t, env)
+	env.CreateNamespace(t, "openshift", nil)
+	env.CreateNamespace(t, flag.TargetNamespace, nil)
+	env.SimulateCluster(flag.TargetNamespace)
+
+	cfg := newConfig(flag.ClusterCRName, flag.TargetNamespace)
+	cfg.Namespace = ""
+	env.Create(t, cfg)
+
+	cfg = reconcileUntil(t, env, r, flag.ClusterCRName, op.InstalledStatus)
+	installed := cfg.Status.Conditions[0]
+	if installed.PipelineVersion != cfg.Status.DesiredPipelineVersion || installed.PipelineVersion == "" {
+		t.Errorf("expected pipeline version %q, got %q", cfg.Status.DesiredPipelineVersion, installed.PipelineVersion)
+	}
+	if installed.TriggersVersion != cfg.Status.DesiredTriggersVersion || installed.TriggersVersion == "" {
+		t.Errorf("expected triggers version %q, got %q", cfg.Status.DesiredTriggersVersion, installed.TriggersVersion)
+	}
+
+	for _, name := range []string{flag.PipelineControllerName, flag.PipelineWebhookName,
+		flag.TriggerControllerName, flag.TriggerWebhookName} {
+		d := &appsv1.Deployment{}
+		key := types.NamespacedName{Namespace: flag.TargetNamespace, Name: name}
+		assertNoEror(env.Client.Get(context.TODO(), key, d), "deployment "+name, t)
+		if !metav1.IsControlledBy(d, cfg) {
+			t.Errorf("expected deployment %s to be owned by config %s, got %v", name, cfg.Name, d.OwnerReferences)
+		}
+	}
+
+	assertProvider(t, env, "git-clone", flag.ProviderTypeRedHat)
+	assertProvider(t, env, "echo", flag.ProviderTypeCommunity)
+	tkn := &unstructured.Unstructured{}
+	tkn.SetGroupVersionKind(schema.GroupVersionKind{Group: "console.openshift.io", Version: "v1", Kind: "ConsoleCLIDownload"})
+	assertNoEror(env.Client.Get(context.TODO(), types.NamespacedName{Name: "tkn"}, tkn), "console cli download", t)
+	links, _, _ := unstructured.NestedSlice(tkn.Object, "spec", "links")
+	if len(links) == 0 || !strings.HasPrefix(links[0].(map[string]interface{})["href"].(string), flag.TknDownloadURL) {
+		t.Errorf("expected tkn download links at %s, got %v", flag.TknDownloadURL, links)
+	}
+
+	for _, task := range []string{"git-clone", "echo"} {
+		snippet := &unstructured.Unstructured{}
+		snippet.SetGroupVersionKind(schema.GroupVersionKind{Group: "console.openshift.io", Version: "v1", Kind: "ConsoleYAMLSample"})
+		assertNoEror(env.Client.Get(context.TODO(), types.NamespacedName{Name: task + "-snippet"}, snippet), "snippet of "+task, t)
+	}
+
+	// an installed config that is up to date is left alone
+	res, err := r.Reconcile(newRequest(flag.ClusterCRName, ""))
+	assertNoEror(err, "reconcile installed config", t)
+	if res != (reconcile.Result{}) {
+		t.Errorf("expected no requeue of the installed config, got %+v", res)
+	}
+
+	var components []string
+	for _, c := range cfg.Status.Inventory {
+		components = append(components, c.Component)
+	}
+	if want := "addons,community,pipeline,triggers"; strings.Join(components, ",") != want {
+		t.Errorf("expected the inventory of %s, got %v", want, cfg.Status.Inventory)
+	}
+
+	// a deleted cluster task is found missing from the inventory and restored
+	ct := &unstructured.Unstructured{}
+	ct.SetGroupVersionKind(schema.GroupVersionKind{Group: "tekton.dev", Version: "v1beta1", Kind: "ClusterTask"})
+	assertNoEror(env.Client.Get(context.TODO(), types.NamespacedName{Name: "git-clone"}, ct), "cluster task git-clone", t)
+	assertNoEror(env.Client.Delete(context.TODO(), ct), "delete cluster task git-clone", t)
+	env.Eventually(t, "git-clone to be deleted", func() (bool, error) {
+		err := env.Client.Get(context.TODO(), types.NamespacedName{Name: "git-clone"}, ct.DeepCopy())
+		return apierrors.IsNotFound(err), client.IgnoreNotFound(err)
+	})
+	// the inventory was just checked, check it again right away
+	r.instanceFor(flag.ClusterCRName).driftChecked = time.Time{}
+	reconcileUntil(t, env, r, flag.ClusterCRName, op.InstalledStatus)
+	assertProvider(t, env, "git-clone", flag.ProviderTypeRedHat)
+}
+
+func TestIntegrationInstallFailure(t *testing.T) {
+	env := integration.Start(t)
+	defer env.Stop(t)
+
+	deadline := flag.PhaseDeadline
+	flag.PhaseDeadline = 5 * time.Second
+	defer func() { flag.PhaseDeadline = deadline }()
+
+	r := newIntegrationReconciler(t, env)
+	env.CreateNamespace(t, "openshift", nil)
+	env.CreateNamespace(t, flag.TargetNamespace, nil)
+
+	cfg := newConfig(flag.ClusterCRName, flag.TargetNamespace)
+	cfg.Namespace = ""
+	env.Create(t, cfg)
+
+	// the pipeline is applied, then the controller pod cannot pull its image
+	reconcileUntil(t, env, r, flag.ClusterCRName, op.AppliedPipeline)
+	env.FailPods(t, flag.TargetNamespace, flag.PipelineControllerName, "ImagePullBackOff")
+	env.SimulateCluster(flag.TargetNamespace)
+
+	cfg = reconcileUntil(t, env, r, flag.ClusterCRName, op.FailedStatus)
+	details := cfg.Status.Conditions[0].Details
+	if !strings.Contains(details, "validate-pipeline") || !strings.Contains(details, "ImagePullBackOff") {
+		t.Errorf("expected the validation of the pipeline to fail on the image pull, got %q", details)
+	}
+}
+
+// newIntegrationReconciler reads the resources shipped with the operator, and
+// a local catalog task instead of the community resources of github.com
+func newIntegrationReconciler(t *testing.T, env *integration.Env) *ReconcileConfig {
+	t.Helper()
+
+	urls := flag.CommunityResourceURLs
+	flag.CommunityResourceURLs = []string{filepath.Join("test", "integration", "testdata", "community", "echo.yaml")}
+	defer func() { flag.CommunityResourceURLs = urls }()
+
+	rec, err := newReconciler(env.Manager)
+	if err != nil {
+		t.Fatalf("failed to create the reconciler: %v", err)
+	}
+	r := rec.(*ReconcileConfig)
+	// the tests call Reconcile in a loop rather than through the watches, so
+	// the status is read back without waiting for the cache
+	r.client = env.Client
+	return r
+}
+
+// reconcileUntil reconciles the Config name, ignoring the requeue delays,
+// until its install status is want
+func reconcileUntil(t *testing.T, env *integration.Env, r *ReconcileConfig, name string, want op.InstallStatus) *op.Config {
+	t.Helper()
+
+	cfg := &op.Config{}
+	env.Eventually(t, "install status "+string(want), func() (bool, error) {
+		if _, err := r.Reconcile(newRequest(name, "")); err != nil {
+			t.Logf("reconcile failed: %v", err)
+		}
+		cfg = &op.Config{}
+		if err := env.Client.Get(context.TODO(), types.NamespacedName{Name: name}, cfg); err != nil {
+			return false, err
+		}
+		if code := cfg.InstallStatus(); code == op.FailedStatus && want != op.FailedStatus {
+			return false, fmt.Errorf("install failed: %s", cfg.Status.Conditions[0].Details)
+		}
+		return cfg.InstallStatus() == want, nil
+	})
+	return cfg
+}
+
+func assertProvider(t *testing.T, env *integration.Env, task, provider string) {
+	t.Helper()
+
+	ct := &unstructured.Unstructured{}
+	ct.SetGroupVersionKind(schema.GroupVersionKind{Group: "tekton.dev", Version: "v1beta1", Kind: "ClusterTask"})
+	if err := env.Client.Get(context.TODO(), types.NamespacedName{Name: task}, ct); err != nil {
+		t.Fatalf("failed to get cluster task %s: %v", task, err)
+	}
+	if got := ct.GetLabels()[flag.LabelProviderType]; got != provider {
+		t.Errorf("expected cluster task %s to be provided by %s, got %q", task, provider, got)
+	}
+}
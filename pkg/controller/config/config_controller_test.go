@@ -2,12 +2,14 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	rt "runtime"
 	"strings"
 	"testing"
+	"time"
 
 	mfc "github.com/manifestival/controller-runtime-client"
 	mf "github.com/manifestival/manifestival"
@@ -16,7 +18,9 @@ import (
 	trnsfm "github.com/tektoncd/operator/pkg/utils/transform"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -87,6 +91,48 @@ func TestConfigControllerReplaceImages(t *testing.T) {
 	})
 }
 
+func TestConfigControllerDeploymentOverrides(t *testing.T) {
+	var (
+		configName = "cluster"
+		namespace  = "openshift-pipelines"
+		replicas   = int32(2)
+	)
+
+	// GIVEN
+	config := newConfig(configName, namespace)
+	config.Spec.Pipeline.Deployments = map[string]op.DeploymentOverride{
+		flag.PipelineControllerName: {
+			Replicas:     &replicas,
+			NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+		},
+	}
+	cl := feedConfigMock(config)
+	pipelines, err := mfFor("pipelines", cl)
+	assertNoEror(err, "failed to create manifestival for pipelines;", t)
+	req := newRequest(configName, namespace)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, pipeline: pipelines}
+
+	// WHEN
+	_, err = r.applyPipeline(req, config)
+
+	// THEN
+	assertNoEror(err, "failed to reconcile for applyPipeline;", t)
+	dep := &appsv1.Deployment{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineControllerName, Namespace: namespace}, dep)
+	assertNoEror(err, "failed to get pipeline controller;", t)
+	if *dep.Spec.Replicas != replicas {
+		t.Errorf("expected %d replicas, got %d", replicas, *dep.Spec.Replicas)
+	}
+	if _, ok := dep.Spec.Template.Spec.NodeSelector["node-role.kubernetes.io/infra"]; !ok {
+		t.Errorf("expected infra node selector, got %v", dep.Spec.Template.Spec.NodeSelector)
+	}
+
+	config.Spec.Pipeline.Deployments["tekton-pipelines-unknown"] = op.DeploymentOverride{}
+	if err := unknownDeployments("pipeline", pipelines, config.Spec.Pipeline.Deployments); err == nil {
+		t.Error("expected an error for an override of an unknown deployment")
+	}
+}
+
 func TestValidateDeployment(t *testing.T) {
 	t.Run("rollout success", func(t *testing.T) {
 		replicas := int32(1)
@@ -286,7 +332,7 @@ func feedConfigMock(config *op.Config) client.Client {
 
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(op.SchemeGroupVersion, config)
+	s.AddKnownTypes(op.SchemeGroupVersion, config, &op.ConfigList{})
 
 	// Create a fake client to mock API calls.
 	return fake.NewFakeClientWithScheme(s, objs...)
@@ -300,7 +346,7 @@ func feedAll(config *op.Config, deployments []*appsv1.Deployment, t *testing.T)
 
 	// Register operator types with the runtime scheme.
 	s := scheme.Scheme
-	s.AddKnownTypes(op.SchemeGroupVersion, config)
+	s.AddKnownTypes(op.SchemeGroupVersion, config, &op.ConfigList{})
 	if err := appsv1.AddToScheme(s); err != nil {
 		t.Fatalf("Unable to add deployment scheme: (%v)", err)
 	}
@@ -308,3 +354,198 @@ func feedAll(config *op.Config, deployments []*appsv1.Deployment, t *testing.T)
 	// Create a fake client to mock API calls.
 	return fake.NewFakeClientWithScheme(s, objs...)
 }
+
+func TestRetryOrFail(t *testing.T) {
+	configName := "cluster"
+	ns := "openshift-pipelines"
+
+	t.Run("transient errors are retried with backoff", func(t *testing.T) {
+		config := newConfig(configName, ns)
+		cl := feedConfigMock(config)
+		r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+		cond := op.ConfigCondition{Code: op.PipelineApplyError, Version: flag.TektonVersion}
+		for attempt := int32(1); attempt <= 3; attempt++ {
+			result, err := r.retryOrFail(config, cond, fmt.Errorf("connection refused"))
+			assertNoEror(err, "failed to retry;", t)
+			assertInstallStatus(t, config, op.PipelineApplyError)
+			if got := config.Status.Conditions[0].Attempts; got != attempt {
+				t.Fatalf("expected attempt %d, got %d", attempt, got)
+			}
+			if result.RequeueAfter != backoff(attempt) {
+				t.Fatalf("expected requeue after %v, got %v", backoff(attempt), result.RequeueAfter)
+			}
+		}
+		if len(config.Status.Conditions) != 1 {
+			t.Fatalf("expected attempts to share a condition, got %d conditions", len(config.Status.Conditions))
+		}
+	})
+
+	t.Run("permanent errors fail the install", func(t *testing.T) {
+		config := newConfig(configName, ns)
+		cl := feedConfigMock(config)
+		r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+		cond := op.ConfigCondition{Code: op.PipelineApplyError, Version: flag.TektonVersion}
+		result, err := r.retryOrFail(config, cond, permanentError{fmt.Errorf("bad manifest")})
+		assertNoEror(err, "failed to mark failed;", t)
+		assertInstallStatus(t, config, op.FailedStatus)
+		if result.Requeue || result.RequeueAfter != 0 {
+			t.Fatalf("expected no requeue for failed install, got %v", result)
+		}
+	})
+
+	t.Run("phases exceeding the deadline fail the install", func(t *testing.T) {
+		config := newConfig(configName, ns)
+		config.Status.Conditions = []op.ConfigCondition{{
+			Code:               op.AppliedPipeline,
+			Version:            flag.TektonVersion,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * flag.PhaseDeadline)),
+		}}
+		cl := feedConfigMock(config)
+		r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+		_, err := r.waitOrFail(config, op.ConfigCondition{Code: op.AppliedPipeline, Version: flag.TektonVersion})
+		assertNoEror(err, "failed to mark failed;", t)
+		assertInstallStatus(t, config, op.FailedStatus)
+	})
+}
+
+func TestRetryFailed(t *testing.T) {
+	config := newConfig("cluster", "openshift-pipelines")
+	config.Status.Conditions = []op.ConfigCondition{
+		{Code: op.FailedStatus, Version: flag.TektonVersion},
+		{Code: op.PipelineValidateError, Version: flag.TektonVersion},
+	}
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+	req := newRequest("cluster", "openshift-pipelines")
+
+	_, err := r.retryFailed(req, config)
+	assertNoEror(err, "failed to wait on failed install;", t)
+	assertInstallStatus(t, config, op.FailedStatus)
+
+	config.Annotations = map[string]string{flag.AnnotationRetry: "true"}
+	_, err = r.retryFailed(req, config)
+	assertNoEror(err, "failed to retry failed install;", t)
+	assertInstallStatus(t, config, op.PipelineValidateError)
+	if _, ok := config.Annotations[flag.AnnotationRetry]; ok {
+		t.Fatalf("expected retry annotation to be removed")
+	}
+}
+
+func TestReconcileRequest(t *testing.T) {
+	config := newConfig(flag.ResourceWatched, "")
+	config.Spec.TargetNamespace = "openshift-pipelines"
+	config.Annotations = map[string]string{flag.AnnotationReconcileRequest: "2020-11-02T10:00:00Z"}
+	config.Status.Conditions = []op.ConfigCondition{{Code: op.InstalledStatus, Version: flag.TektonVersion}}
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+	req := newRequest(flag.ResourceWatched, "")
+
+	result, err := r.Reconcile(req)
+	assertNoEror(err, "failed to handle reconcile request;", t)
+	if !result.Requeue {
+		t.Fatalf("expected reconcile request to requeue")
+	}
+
+	updated := &op.Config{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.ResourceWatched}, updated)
+	assertNoEror(err, "failed to get config;", t)
+	assertInstallStatus(t, updated, op.EmptyStatus)
+	if updated.Status.LastReconcileRequest != "2020-11-02T10:00:00Z" {
+		t.Fatalf("expected handled reconcile request to be recorded, got %q", updated.Status.LastReconcileRequest)
+	}
+}
+
+func TestSpecChangeIsApplied(t *testing.T) {
+	var (
+		namespace = "openshift-pipelines"
+		replicas  = int32(2)
+	)
+
+	config := newConfig(flag.ResourceWatched, namespace)
+	config.Generation = 2
+	config.Spec.Pipeline.Deployments = map[string]op.DeploymentOverride{
+		flag.PipelineControllerName: {Replicas: &replicas},
+	}
+	config.Status.Conditions = []op.ConfigCondition{{
+		Code:               op.InstalledStatus,
+		Version:            flag.TektonVersion,
+		ObservedGeneration: 2,
+	}}
+	cl := feedConfigMock(config)
+	pipelines, err := mfFor("pipelines", cl)
+	assertNoEror(err, "failed to create manifestival for pipelines;", t)
+	req := newRequest(flag.ResourceWatched, "")
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, pipeline: pipelines}
+	// the drift check is not due
+	r.instanceFor(config.Name).driftChecked = timeNow()
+
+	_, err = r.validateVersion(req, config)
+	assertNoEror(err, "failed to validate the version;", t)
+	dep := &appsv1.Deployment{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineControllerName, Namespace: namespace}, dep)
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the observed generation not to be applied again, got %v", err)
+	}
+
+	config.Generation = 3
+	_, err = r.validateVersion(req, config)
+	assertNoEror(err, "failed to apply the changed spec;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineControllerName, Namespace: namespace}, dep)
+	assertNoEror(err, "failed to get pipeline controller;", t)
+	if *dep.Spec.Replicas != replicas {
+		t.Errorf("expected %d replicas, got %d", replicas, *dep.Spec.Replicas)
+	}
+}
+
+func TestApplyInPlace(t *testing.T) {
+	live := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "addon", Namespace: "openshift", UID: "in-use"},
+		Data:       map[string]string{"step": "old"},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, live)
+	desired := &unstructured.Unstructured{}
+	desired.SetAPIVersion("v1")
+	desired.SetKind("ConfigMap")
+	desired.SetNamespace("openshift")
+	desired.SetName("addon")
+	desired.Object["data"] = map[string]interface{}{"step": "new"}
+	manifest, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*desired}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+
+	assertNoEror(applyInPlace(manifest), "failed to apply addons;", t)
+
+	updated := &v1.ConfigMap{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "openshift", Name: "addon"}, updated)
+	assertNoEror(err, "failed to get addon;", t)
+	if updated.UID != "in-use" {
+		t.Errorf("expected the addon to be updated in place, got uid %q", updated.UID)
+	}
+	if updated.Data["step"] != "new" {
+		t.Errorf("expected the addon to be updated, got %v", updated.Data)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	expected := map[int32]time.Duration{
+		1:  retryBaseDelay,
+		2:  2 * retryBaseDelay,
+		3:  4 * retryBaseDelay,
+		50: retryMaxDelay,
+	}
+	for attempts, delay := range expected {
+		if got := backoff(attempts); got != delay {
+			t.Errorf("expected backoff %v for %d attempts, got %v", delay, attempts, got)
+		}
+	}
+}
+
+func assertInstallStatus(t *testing.T, cfg *op.Config, status op.InstallStatus) {
+	t.Helper()
+
+	if cfg.InstallStatus() != status {
+		t.Fatalf("expected install status %s, got %s", status, cfg.InstallStatus())
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/pkg/utils/transform"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	// pipelineHADeployments are scaled when high availability is enabled
+	pipelineHADeployments = []string{flag.PipelineControllerName, flag.PipelineWebhookName}
+
+	// triggersHADeployments are scaled when high availability is enabled. The
+	// triggers controller of the bundled releases does not elect a leader, so
+	// only its webhook can run several replicas
+	triggersHADeployments = []string{flag.TriggerWebhookName}
+)
+
+// haReplicas returns the number of replicas requested by the high availability
+// settings of cfg, or 0 when high availability is disabled
+func haReplicas(cfg *op.Config) int32 {
+	ha := cfg.Spec.HighAvailability
+	if ha == nil {
+		return 0
+	}
+	if ha.Replicas < flag.DefaultHAReplicas {
+		return flag.DefaultHAReplicas
+	}
+	return ha.Replicas
+}
+
+// haTransformers returns the transformers applying the high availability
+// settings of cfg to the given deployments
+func haTransformers(cfg *op.Config, deployments []string) []mf.Transformer {
+	replicas := haReplicas(cfg)
+	if replicas == 0 {
+		return nil
+	}
+	return []mf.Transformer{transform.HighAvailability(replicas, deployments...)}
+}
+
+// withHighAvailability returns the release m with a PodDisruptionBudget for
+// each of the given deployments when high availability is enabled, replacing
+// the budgets of the same name shipped with the release. When it is disabled
+// the budgets generated earlier, and not shipped with the release, are deleted
+func withHighAvailability(cfg *op.Config, m mf.Manifest, deployments []string) (mf.Manifest, error) {
+	budgets, err := podDisruptionBudgets(m, deployments)
+	if err != nil {
+		return m, err
+	}
+	generated, err := mf.ManifestFrom(mf.Slice(budgets), mf.UseClient(m.Client))
+	if err != nil {
+		return m, err
+	}
+	shipped := m.Filter(mf.ByKind("PodDisruptionBudget"))
+	isShipped := func(u *unstructured.Unstructured) bool {
+		return len(shipped.Filter(mf.ByName(u.GetName())).Resources()) > 0
+	}
+
+	if haReplicas(cfg) == 0 {
+		stale, err := generated.Filter(mf.Not(isShipped)).Transform(mf.InjectNamespace(cfg.Spec.TargetNamespace))
+		if err != nil {
+			return m, err
+		}
+		return m, stale.Delete()
+	}
+
+	isGenerated := func(u *unstructured.Unstructured) bool {
+		return u.GetKind() == "PodDisruptionBudget" && len(generated.Filter(mf.ByName(u.GetName())).Resources()) > 0
+	}
+	return m.Filter(mf.Not(isGenerated)).Append(generated), nil
+}
+
+// podDisruptionBudgets returns a budget allowing one unavailable pod for each
+// of the given deployments found in m
+func podDisruptionBudgets(m mf.Manifest, deployments []string) ([]unstructured.Unstructured, error) {
+	var budgets []unstructured.Unstructured
+	for _, u := range m.Filter(mf.ByKind("Deployment")).Resources() {
+		if !transform.ItemInSlice(u.GetName(), deployments) {
+			continue
+		}
+		d := &appsv1.Deployment{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, d); err != nil {
+			return nil, err
+		}
+
+		maxUnavailable := intstr.FromInt(1)
+		pdb := &policyv1beta1.PodDisruptionBudget{
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &maxUnavailable,
+				Selector:       d.Spec.Selector,
+			},
+		}
+		pdb.SetName(d.Name)
+		pdb.SetNamespace(d.Namespace)
+		pdb.SetLabels(d.Labels)
+
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pdb)
+		if err != nil {
+			return nil, err
+		}
+		budget := unstructured.Unstructured{Object: obj}
+		budget.SetAPIVersion("policy/v1beta1")
+		budget.SetKind("PodDisruptionBudget")
+		budgets = append(budgets, budget)
+	}
+	return budgets, nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestHaReplicas(t *testing.T) {
+	config := newConfig("cluster", "openshift-pipelines")
+	if replicas := haReplicas(config); replicas != 0 {
+		t.Errorf("expected high availability to be disabled, got %d replicas", replicas)
+	}
+
+	config.Spec.HighAvailability = &op.HighAvailability{}
+	if replicas := haReplicas(config); replicas != flag.DefaultHAReplicas {
+		t.Errorf("expected %d replicas, got %d", flag.DefaultHAReplicas, replicas)
+	}
+
+	config.Spec.HighAvailability.Replicas = 3
+	if replicas := haReplicas(config); replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", replicas)
+	}
+}
+
+func TestApplyPipelineHighAvailability(t *testing.T) {
+	var (
+		configName = "cluster"
+		namespace  = "openshift-pipelines"
+	)
+
+	config := newConfig(configName, namespace)
+	config.Spec.HighAvailability = &op.HighAvailability{Replicas: 3}
+	cl := feedConfigMock(config)
+	pipelines, err := mfFor("pipelines", cl)
+	assertNoEror(err, "failed to create manifestival for pipelines;", t)
+	req := newRequest(configName, namespace)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, pipeline: pipelines}
+
+	_, err = r.applyPipeline(req, config)
+	assertNoEror(err, "failed to reconcile for applyPipeline;", t)
+
+	for _, name := range pipelineHADeployments {
+		pdb := &policyv1beta1.PodDisruptionBudget{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, pdb)
+		assertNoEror(err, "failed to get pod disruption budget;", t)
+		if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 || pdb.Spec.MinAvailable != nil {
+			t.Errorf("expected budget %s to allow one unavailable pod, got %v", name, pdb.Spec)
+		}
+	}
+
+	// disabling high availability removes the budgets that are not shipped
+	config.Spec.HighAvailability = nil
+	_, err = r.applyPipeline(req, config)
+	assertNoEror(err, "failed to reconcile for applyPipeline;", t)
+
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineControllerName, Namespace: namespace}, pdb)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected budget %s to be deleted, got %v", flag.PipelineControllerName, err)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineWebhookName, Namespace: namespace}, pdb)
+	assertNoEror(err, "expected the shipped webhook budget to be kept;", t)
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// clusterResource matches the cluster scoped resources of a transformed
+// manifest: the CRDs, the webhook configurations, the cluster roles and their
+// bindings
+func clusterResource(u *unstructured.Unstructured) bool {
+	return u.GetNamespace() == ""
+}
+
+// instance holds the manifests of a single Config transformed for its target
+// namespace, so that several Configs can install side by side
+type instance struct {
+	pipeline  mf.Manifest
+	triggers  mf.Manifest
+	addons    mf.Manifest
+	community mf.Manifest
+
+	// server of the tkn archives, once applied
+	cliDownloads mf.Manifest
+
+	// releases selected by the spec, before transformation
+	pipelineRelease mf.Manifest
+	triggersRelease mf.Manifest
+
+	// versions of the selected releases
+	pipelineVersion string
+	triggersVersion string
+
+	// proxy settings the controllers are configured with
+	proxy proxySettings
+
+	// components whose cluster tasks installed by earlier operator
+	// versions were adopted by prune
+	adopted map[string]bool
+
+	// last time the installed objects were checked against the inventory
+	driftChecked time.Time
+}
+
+// instanceFor returns the state of the Config name, initialised from the
+// shipped manifests the first time it is seen
+func (r *ReconcileConfig) instanceFor(name string) *instance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.instances == nil {
+		r.instances = map[string]*instance{}
+	}
+	inst, ok := r.instances[name]
+	if !ok {
+		inst = &instance{
+			pipeline:        r.pipeline,
+			triggers:        r.triggers,
+			addons:          r.addons,
+			community:       r.community,
+			pipelineRelease: r.pipeline,
+			triggersRelease: r.triggers,
+		}
+		r.instances[name] = inst
+	}
+	return inst
+}
+
+// forgetInstance drops the state of the Config name once it is deleted
+func (r *ReconcileConfig) forgetInstance(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.instances, name)
+}
+
+// ownsAddons reports whether cfg is the instance designated to install the
+// cluster scoped addons and community resources
+func ownsAddons(cfg *op.Config) bool {
+	return cfg.Name == flag.ResourceWatched
+}
+
+// namespaceConflict returns the name of another Config that already installs
+// into the target namespace of cfg, or "" if there is none. The oldest Config
+// keeps the namespace
+func (r *ReconcileConfig) namespaceConflict(cfg *op.Config) (string, error) {
+	configs := &op.ConfigList{}
+	if err := r.client.List(context.TODO(), configs); err != nil {
+		return "", err
+	}
+
+	sort.Slice(configs.Items, func(i, j int) bool {
+		a, b := configs.Items[i], configs.Items[j]
+		if a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return a.Name < b.Name
+		}
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	})
+
+	for _, other := range configs.Items {
+		if other.Name == cfg.Name {
+			return "", nil
+		}
+		if other.Spec.TargetNamespace == cfg.Spec.TargetNamespace {
+			return other.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// clusterConflicts returns a permanent error listing the cluster scoped
+// resources of m that another Config already installed. The Tekton
+// controllers watch every namespace and each webhook rewrites the webhook
+// configurations, so the resources of a component are never shared: a second
+// instance would take over the webhook configurations and the role bindings
+// of the first one, and both controllers would reconcile every run
+func clusterConflicts(cfg *op.Config, m mf.Manifest) error {
+	owners := map[string][]string{}
+	for _, res := range m.Filter(clusterResource).Resources() {
+		existing, err := m.Client.Get(&res)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		owner := configOwner(existing)
+		if owner == "" || owner == cfg.Name {
+			continue
+		}
+		owners[owner] = append(owners[owner], res.GetKind()+" "+res.GetName())
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	var conflicts []string
+	for owner, resources := range owners {
+		conflicts = append(conflicts, fmt.Sprintf("config %s installed %s", owner, strings.Join(resources, ", ")))
+	}
+	sort.Strings(conflicts)
+	return permanentError{fmt.Errorf("conflicts with another instance, its webhook would rewrite the shared webhook configurations "+
+		"and both controllers would reconcile every run: %s", strings.Join(conflicts, "; "))}
+}
+
+// ownedBy matches the resources whose live object was installed by the Config name
+func ownedBy(m mf.Manifest, name string) mf.Predicate {
+	return func(u *unstructured.Unstructured) bool {
+		existing, err := m.Client.Get(u)
+		if err != nil {
+			return false
+		}
+		return configOwner(existing) == name
+	}
+}
+
+// configOwner returns the name of the Config that installed u, if any. Owner
+// references are not set on cluster scoped resources, so the instance label
+// is used instead
+func configOwner(u *unstructured.Unstructured) string {
+	return u.GetLabels()[flag.LabelInstance]
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNamespaceConflict(t *testing.T) {
+	prod := newConfig(flag.ResourceWatched, "openshift-pipelines")
+	prod.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	canary := newConfig("canary", "openshift-pipelines")
+	canary.CreationTimestamp = metav1.Now()
+
+	cl := feedConfigMock(prod)
+	assertNoEror(cl.Create(context.TODO(), canary), "failed to create canary config;", t)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+	other, err := r.namespaceConflict(prod)
+	assertNoEror(err, "failed to find conflicts;", t)
+	if other != "" {
+		t.Errorf("expected oldest config to keep the namespace, got conflict with %s", other)
+	}
+
+	other, err = r.namespaceConflict(canary)
+	assertNoEror(err, "failed to find conflicts;", t)
+	if other != flag.ResourceWatched {
+		t.Errorf("expected conflict with %s, got %q", flag.ResourceWatched, other)
+	}
+}
+
+func TestClusterConflicts(t *testing.T) {
+	binding := &unstructured.Unstructured{}
+	binding.SetAPIVersion("rbac.authorization.k8s.io/v1")
+	binding.SetKind("ClusterRoleBinding")
+	binding.SetName("tekton-pipelines-controller-cluster-access")
+	binding.SetLabels(map[string]string{flag.LabelInstance: flag.ResourceWatched})
+	role := &unstructured.Unstructured{}
+	role.SetAPIVersion("rbac.authorization.k8s.io/v1")
+	role.SetKind("Role")
+	role.SetNamespace("openshift-pipelines-canary")
+	role.SetName("tekton-pipelines-controller")
+	role.SetLabels(map[string]string{flag.LabelInstance: flag.ResourceWatched})
+
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, binding.DeepCopy(), role.DeepCopy())
+	manifest, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*binding, *role}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+
+	err = clusterConflicts(newConfig(flag.ResourceWatched, "openshift-pipelines"), manifest)
+	assertNoEror(err, "expected the installing config not to conflict;", t)
+
+	err = clusterConflicts(newConfig("canary", "openshift-pipelines-canary"), manifest)
+	if err == nil || !isPermanent(err) {
+		t.Fatalf("expected a permanent conflict error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "ClusterRoleBinding "+binding.GetName()) {
+		t.Errorf("expected the conflict to list %s, got %v", binding.GetName(), err)
+	}
+	if strings.Contains(err.Error(), "Role "+role.GetName()) {
+		t.Errorf("expected the namespaced %s not to conflict, got %v", role.GetName(), err)
+	}
+}
+
+func TestSecondaryInstanceSkipsAddons(t *testing.T) {
+	config := newConfig("canary", "openshift-pipelines-canary")
+	config.Status.Conditions = []op.ConfigCondition{{Code: op.ValidatedTriggers, Version: flag.TektonVersion}}
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+	req := newRequest("canary", "")
+
+	_, err := r.applyAddons(req, config)
+	assertNoEror(err, "failed to skip addons;", t)
+	assertInstallStatus(t, config, op.AppliedAddons)
+
+	_, err = r.applyCommunityResources(req, config)
+	assertNoEror(err, "failed to skip community resources;", t)
+	assertInstallStatus(t, config, op.InstalledStatus)
+}
@@ -0,0 +1,450 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// driftInterval is the minimum time between two checks of the objects
+// installed for a Config against its inventory
+var driftInterval = 10 * time.Minute
+
+// inventoryEntry records an object applied for a component
+type inventoryEntry struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+
+	// Hash identifies the content the object was applied with
+	Hash    string      `json:"hash"`
+	Applied metav1.Time `json:"applied"`
+}
+
+func (e inventoryEntry) String() string {
+	if e.Namespace == "" {
+		return e.Kind + " " + e.Name
+	}
+	return e.Kind + " " + e.Namespace + "/" + e.Name
+}
+
+// entryFor returns the entry of u, without the hash of its content
+func entryFor(u *unstructured.Unstructured) inventoryEntry {
+	return inventoryEntry{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+	}
+}
+
+// key identifies the object of e in a manifest
+func (e inventoryEntry) key() string {
+	return e.APIVersion + "/" + e.Kind + "/" + e.Namespace + "/" + e.Name
+}
+
+func (e inventoryEntry) object() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(e.APIVersion)
+	u.SetKind(e.Kind)
+	u.SetNamespace(e.Namespace)
+	u.SetName(e.Name)
+	return u
+}
+
+// newInventory lists the objects of m, sorted by kind, namespace and name,
+// with the hash of the content they are applied with
+func newInventory(m mf.Manifest, applied metav1.Time) ([]inventoryEntry, error) {
+	var entries []inventoryEntry
+	for _, u := range m.Resources() {
+		hash, err := contentHash(u.Object)
+		if err != nil {
+			return nil, err
+		}
+		entry := entryFor(&u)
+		entry.Hash, entry.Applied = hash, applied
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].String() < entries[j].String()
+	})
+	return entries, nil
+}
+
+// contentHash identifies the content of an object
+func contentHash(content map[string]interface{}) (string, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16], nil
+}
+
+// project returns the fields of live at the paths set in desired, leaving out
+// the fields added by the apiserver and the controllers. The items of a list
+// are projected one by one when both lists have the same length
+func project(desired, live interface{}) interface{} {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return live
+		}
+		out := make(map[string]interface{}, len(d))
+		for k, v := range d {
+			if lv, found := l[k]; found {
+				out[k] = project(v, lv)
+			}
+		}
+		return out
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			return live
+		}
+		out := make([]interface{}, len(d))
+		for i := range d {
+			out[i] = project(d[i], l[i])
+		}
+		return out
+	}
+	return live
+}
+
+// foreignFields returns the fields of the object of e that other controllers
+// own, e.g. the replicas of a Deployment scaled by a HorizontalPodAutoscaler,
+// in the Deployments named by scaled. The fields the operator does not apply,
+// like the CA bundles injected into the webhook configurations, are already
+// left out by project
+func foreignFields(e inventoryEntry, scaled map[string]bool) [][]string {
+	if e.Kind == "Deployment" && scaled[e.Namespace+"/"+e.Name] {
+		return [][]string{{"spec", "replicas"}}
+	}
+	return nil
+}
+
+// ownedHash identifies the content of an object without the fields other
+// controllers own
+func ownedHash(content map[string]interface{}, foreign [][]string) (string, error) {
+	if len(foreign) > 0 {
+		content = runtime.DeepCopyJSON(content)
+		for _, fields := range foreign {
+			unstructured.RemoveNestedField(content, fields...)
+		}
+	}
+	return contentHash(content)
+}
+
+// inventoryDigest identifies the objects of entries and their applied content
+func inventoryDigest(entries []inventoryEntry) string {
+	h := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s/%s=%s\n", e.APIVersion, e, e.Hash)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// componentInventory summarises the entries of component for the status
+func componentInventory(component string, entries []inventoryEntry) op.ComponentInventory {
+	var applied metav1.Time
+	for _, e := range entries {
+		if applied.Before(&e.Applied) {
+			applied = e.Applied
+		}
+	}
+	return op.ComponentInventory{
+		Component:   component,
+		Count:       int32(len(entries)),
+		Digest:      inventoryDigest(entries),
+		LastApplied: applied,
+	}
+}
+
+// inventoryStatus summarises the inventory for the status, by component
+func inventoryStatus(inventory map[string][]inventoryEntry) []op.ComponentInventory {
+	var status []op.ComponentInventory
+	for component, entries := range inventory {
+		if len(entries) > 0 {
+			status = append(status, componentInventory(component, entries))
+		}
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Component < status[j].Component
+	})
+	return status
+}
+
+// inventoryKey returns the ConfigMap listing the objects installed for the
+// Config name. It is kept in the operator namespace, so that it outlives the
+// target namespace, or in the default target namespace when the operator runs
+// locally
+func (r *ReconcileConfig) inventoryKey(name string) types.NamespacedName {
+	namespace := r.inventoryNamespace
+	if namespace == "" {
+		namespace = flag.TargetNamespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: "config-inventory-" + name}
+}
+
+// recordInventory replaces the inventory of the component of cfg with the
+// objects of m once they are applied, and summarises it in the status of cfg
+func (r *ReconcileConfig) recordInventory(cfg *op.Config, component string, m mf.Manifest) error {
+	now := metav1.Now()
+	entries, err := newInventory(m, now)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	key := r.inventoryKey(cfg.Name)
+	cm := &corev1.ConfigMap{}
+	err = r.reader().Get(context.TODO(), key, cm)
+	switch {
+	case apierrors.IsNotFound(err):
+		if len(entries) == 0 {
+			break
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: key.Namespace,
+				Name:      key.Name,
+				Labels: map[string]string{
+					flag.LabelInstance:  cfg.Name,
+					flag.LabelManagedBy: flag.ManagedBy,
+					flag.LabelPartOf:    flag.PartOf,
+				},
+			},
+			Data: map[string]string{component: string(data)},
+		}
+		err = r.client.Create(context.TODO(), cm)
+	case err == nil:
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		if len(entries) == 0 {
+			delete(cm.Data, component)
+		} else {
+			cm.Data[component] = string(data)
+		}
+		err = r.client.Update(context.TODO(), cm)
+	}
+	if err != nil {
+		return err
+	}
+
+	var inventory []op.ComponentInventory
+	for _, c := range cfg.Status.Inventory {
+		if c.Component != component {
+			inventory = append(inventory, c)
+		}
+	}
+	if len(entries) > 0 {
+		inventory = append(inventory, componentInventory(component, entries))
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].Component < inventory[j].Component
+	})
+	cfg.Status.Inventory = inventory
+	return nil
+}
+
+// readInventory returns the objects installed for the Config name, by
+// component; it is empty when nothing was recorded
+func (r *ReconcileConfig) readInventory(name string) (map[string][]inventoryEntry, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.reader().Get(context.TODO(), r.inventoryKey(name), cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	inventory := map[string][]inventoryEntry{}
+	for component, data := range cm.Data {
+		var entries []inventoryEntry
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			return nil, fmt.Errorf("invalid inventory of %s: %w", component, err)
+		}
+		inventory[component] = entries
+	}
+	return inventory, nil
+}
+
+// drift returns the objects of the inventory of cfg that are missing from the
+// cluster, e.g. because a user deleted them, and the ones edited since they
+// were applied. An object is edited when its fields applied by the operator,
+// except the ones other controllers own, no longer hash to the recorded hash;
+// this is only known for the objects applied since the operator started, the
+// others are checked for existence. It also returns the drifted objects as
+// they were applied, when they are known
+func (r *ReconcileConfig) drift(cfg *op.Config) (missing []string, edited []string, restore mf.Manifest, err error) {
+	inventory, err := r.readInventory(cfg.Name)
+	if err != nil {
+		return nil, nil, mf.Manifest{}, err
+	}
+
+	inst := r.instanceFor(cfg.Name)
+	manifests := []mf.Manifest{inst.pipeline, inst.triggers, inst.addons, inst.community, inst.cliDownloads}
+	applied := map[string]unstructured.Unstructured{}
+	for _, m := range manifests {
+		for _, u := range m.Resources() {
+			applied[entryFor(&u).key()] = u
+		}
+	}
+
+	namespaces := map[string]bool{}
+	for _, entries := range inventory {
+		for _, e := range entries {
+			if e.Kind == "Deployment" {
+				namespaces[e.Namespace] = true
+			}
+		}
+	}
+	scaled := map[string]bool{}
+	for namespace := range namespaces {
+		hpas := &autoscalingv1.HorizontalPodAutoscalerList{}
+		if err := r.reader().List(context.TODO(), hpas, client.InNamespace(namespace)); err != nil {
+			return nil, nil, mf.Manifest{}, err
+		}
+		for _, hpa := range hpas.Items {
+			if ref := hpa.Spec.ScaleTargetRef; ref.Kind == "Deployment" {
+				scaled[namespace+"/"+ref.Name] = true
+			}
+		}
+	}
+
+	drifted := map[string]bool{}
+	for _, entries := range inventory {
+		for _, e := range entries {
+			desired, known := applied[e.key()]
+			if known {
+				// the manifest in memory may not be the one recorded
+				hash, err := contentHash(desired.Object)
+				known = err == nil && hash == e.Hash
+			}
+
+			live := e.object()
+			err := r.reader().Get(context.TODO(), types.NamespacedName{Namespace: e.Namespace, Name: e.Name}, live)
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				missing = append(missing, e.String())
+				drifted[e.key()] = known
+				continue
+			}
+			if err != nil {
+				return nil, nil, mf.Manifest{}, err
+			}
+			if !known {
+				continue
+			}
+
+			foreign := foreignFields(e, scaled)
+			want, err := ownedHash(desired.Object, foreign)
+			if err != nil {
+				return nil, nil, mf.Manifest{}, err
+			}
+			projected, _ := project(desired.Object, live.Object).(map[string]interface{})
+			got, err := ownedHash(projected, foreign)
+			if err != nil {
+				return nil, nil, mf.Manifest{}, err
+			}
+			if got != want {
+				edited = append(edited, e.String())
+				drifted[e.key()] = true
+			}
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(edited)
+
+	for _, known := range drifted {
+		if !known {
+			return missing, edited, mf.Manifest{}, nil
+		}
+	}
+	if len(drifted) > 0 {
+		restore = manifests[0].Filter(mf.Nothing)
+		for _, m := range manifests {
+			restore = restore.Append(m.Filter(func(u *unstructured.Unstructured) bool {
+				return drifted[entryFor(u).key()]
+			}))
+		}
+	}
+	return missing, edited, restore, nil
+}
+
+// deleteInventory deletes the objects of the inventory of the Config name that
+// it still owns, except the ones matched by keep, then the inventory itself
+// unless it lists kept objects. It covers the objects installed before the
+// operator restarted, which the manifests in memory may not list
+func (r *ReconcileConfig) deleteInventory(name string, keep mf.Predicate) error {
+	inventory, err := r.readInventory(name)
+	if err != nil {
+		return err
+	}
+
+	kept := map[string]string{}
+	propagation := client.PropagationPolicy(metav1.DeletePropagationForeground)
+	for component, entries := range inventory {
+		var keptEntries []inventoryEntry
+		for _, e := range entries {
+			live := e.object()
+			if keep(live) {
+				keptEntries = append(keptEntries, e)
+				continue
+			}
+			err := r.reader().Get(context.TODO(), types.NamespacedName{Namespace: e.Namespace, Name: e.Name}, live)
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// shared objects may have been taken over by another Config
+			if configOwner(live) != name {
+				continue
+			}
+			if err := r.client.Delete(context.TODO(), live, propagation); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		if len(keptEntries) > 0 {
+			data, err := json.Marshal(keptEntries)
+			if err != nil {
+				return err
+			}
+			kept[component] = string(data)
+		}
+	}
+
+	cm := &corev1.ConfigMap{}
+	key := r.inventoryKey(name)
+	if len(kept) > 0 {
+		if err := r.reader().Get(context.TODO(), key, cm); err != nil {
+			return err
+		}
+		cm.Data = kept
+		return r.client.Update(context.TODO(), cm)
+	}
+	cm.Namespace, cm.Name = key.Namespace, key.Name
+	return client.IgnoreNotFound(r.client.Delete(context.TODO(), cm))
+}
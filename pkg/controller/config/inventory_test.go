@@ -0,0 +1,227 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestRecordInventory(t *testing.T) {
+	const namespace = "openshift-pipelines"
+	config := newConfig(flag.ResourceWatched, namespace)
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, inventoryNamespace: "openshift-operators"}
+
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{
+		*newConfigMap("config-logging", namespace, nil),
+		*newConfigMap("config-defaults", namespace, nil),
+	}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+	assertNoEror(r.recordInventory(config, componentPipeline, m), "failed to record the inventory;", t)
+
+	inventory, err := r.readInventory(config.Name)
+	assertNoEror(err, "failed to read the inventory;", t)
+	var names []string
+	for _, e := range inventory[componentPipeline] {
+		names = append(names, e.String())
+		if e.Hash == "" || e.Applied.IsZero() {
+			t.Errorf("expected %s with its hash and applied time, got %+v", e, e)
+		}
+	}
+	want := []string{"ConfigMap " + namespace + "/config-defaults", "ConfigMap " + namespace + "/config-logging"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected inventory %v, got %v", want, names)
+	}
+	status := config.Status.Inventory
+	if len(status) != 1 || status[0].Component != componentPipeline || status[0].Count != 2 ||
+		status[0].Digest != inventoryDigest(inventory[componentPipeline]) {
+		t.Errorf("expected the pipeline inventory summarised in the status, got %+v", status)
+	}
+
+	// a component without objects is dropped from the inventory
+	assertNoEror(r.recordInventory(config, componentPipeline, mf.Manifest{}), "failed to record the empty inventory;", t)
+	inventory, err = r.readInventory(config.Name)
+	assertNoEror(err, "failed to read the inventory;", t)
+	if _, found := inventory[componentPipeline]; found || len(config.Status.Inventory) != 0 {
+		t.Errorf("expected no pipeline inventory, got %v and status %+v", inventory, config.Status.Inventory)
+	}
+}
+
+func TestDriftAndDeleteInventory(t *testing.T) {
+	const namespace = "openshift-pipelines"
+	config := newConfig(flag.ResourceWatched, namespace)
+	installed := map[string]string{flag.LabelInstance: config.Name}
+	kept := newConfigMap("config-defaults", namespace, installed)
+	deleted := newConfigMap("config-logging", namespace, installed)
+	edited := newConfigMap("feature-flags", namespace, installed)
+	assertNoEror(unstructured.SetNestedField(edited.Object, "false", "data", "disable-home-env-overwrite"), "failed to set data;", t)
+	takenOver := newConfigMap("config-leader-election", namespace, map[string]string{flag.LabelInstance: "canary"})
+
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*kept, *deleted, *edited, *takenOver}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+	r.instanceFor(config.Name).pipeline = m
+	assertNoEror(r.recordInventory(config, componentPipeline, m), "failed to record the inventory;", t)
+	for _, cm := range []*unstructured.Unstructured{kept, edited, takenOver} {
+		typed := &corev1.ConfigMap{}
+		assertNoEror(runtime.DefaultUnstructuredConverter.FromUnstructured(cm.Object, typed), "failed to convert config map;", t)
+		assertNoEror(cl.Create(context.TODO(), typed), "failed to create config map;", t)
+	}
+
+	// the fields added to the applied objects are not an edit
+	live := &corev1.ConfigMap{}
+	assertNoEror(cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: kept.GetName()}, live), "failed to get config map;", t)
+	live.Data = map[string]string{"default-timeout-minutes": "120"}
+	assertNoEror(cl.Update(context.TODO(), live), "failed to update config map;", t)
+	assertNoEror(cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: edited.GetName()}, live), "failed to get config map;", t)
+	live.Data["disable-home-env-overwrite"] = "true"
+	assertNoEror(cl.Update(context.TODO(), live), "failed to update config map;", t)
+
+	missing, changed, restore, err := r.drift(config)
+	assertNoEror(err, "failed to check the inventory;", t)
+	if want := []string{"ConfigMap " + namespace + "/config-logging"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("expected missing objects %v, got %v", want, missing)
+	}
+	if want := []string{"ConfigMap " + namespace + "/feature-flags"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("expected edited objects %v, got %v", want, changed)
+	}
+	var restored []string
+	for _, u := range restore.Resources() {
+		restored = append(restored, u.GetName())
+	}
+	if want := []string{"config-logging", "feature-flags"}; !reflect.DeepEqual(restored, want) {
+		t.Errorf("expected to restore %v, got %v", want, restored)
+	}
+
+	assertNoEror(r.deleteInventory(config.Name, mf.Nothing), "failed to delete the inventory;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: kept.GetName()}, &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected %s to be deleted, got %v", kept.GetName(), err)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: takenOver.GetName()}, &corev1.ConfigMap{})
+	assertNoEror(err, "expected the config map of another config to be kept;", t)
+	err = cl.Get(context.TODO(), r.inventoryKey(config.Name), &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the inventory to be deleted, got %v", err)
+	}
+}
+
+func TestDriftCheckedOncePerInterval(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	now := time.Date(2020, time.November, 2, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	config := newConfig(flag.ResourceWatched, "openshift-pipelines")
+	config.Status.Conditions = []op.ConfigCondition{{Code: op.InstalledStatus, Version: flag.TektonVersion}}
+	r := ReconcileConfig{scheme: scheme.Scheme, client: feedConfigMock(config)}
+	req := newRequest(flag.ResourceWatched, "")
+
+	res, err := r.validateVersion(req, config)
+	assertNoEror(err, "failed to validate the version;", t)
+	if res.RequeueAfter != 0 {
+		t.Errorf("expected the first check not to be delayed, got %+v", res)
+	}
+
+	now = now.Add(time.Minute)
+	res, err = r.validateVersion(req, config)
+	assertNoEror(err, "failed to validate the version;", t)
+	if res.RequeueAfter != driftInterval-time.Minute {
+		t.Errorf("expected the next check in %s, got %+v", driftInterval-time.Minute, res)
+	}
+}
+
+func TestDriftLeavesOutScaledReplicas(t *testing.T) {
+	const namespace = "openshift-pipelines"
+	config := newConfig(flag.ResourceWatched, namespace)
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+	var deployments []unstructured.Unstructured
+	for _, name := range []string{"tekton-pipelines-controller", "tekton-pipelines-webhook"} {
+		replicas := int32(1)
+		d := &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d)
+		assertNoEror(err, "failed to convert deployment;", t)
+		deployments = append(deployments, unstructured.Unstructured{Object: u})
+
+		// both are scaled on the cluster
+		replicas = 3
+		assertNoEror(cl.Create(context.TODO(), d), "failed to create deployment;", t)
+	}
+	m, err := mf.ManifestFrom(mf.Slice(deployments), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+	r.instanceFor(config.Name).pipeline = m
+	assertNoEror(r.recordInventory(config, componentPipeline, m), "failed to record the inventory;", t)
+
+	hpa := &autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "tekton-pipelines-webhook"},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "tekton-pipelines-webhook"},
+			MaxReplicas:    5,
+		},
+	}
+	assertNoEror(cl.Create(context.TODO(), hpa), "failed to create the autoscaler;", t)
+
+	// only the replicas of the Deployment no autoscaler owns are an edit
+	_, edited, _, err := r.drift(config)
+	assertNoEror(err, "failed to check the inventory;", t)
+	if want := []string{"Deployment " + namespace + "/tekton-pipelines-controller"}; !reflect.DeepEqual(edited, want) {
+		t.Errorf("expected edited objects %v, got %v", want, edited)
+	}
+}
+
+func TestDriftRestoresDriftedObjectsOnly(t *testing.T) {
+	const namespace = "openshift-pipelines"
+	config := newConfig(flag.ResourceWatched, namespace)
+	config.Status.Conditions = []op.ConfigCondition{{Code: op.InstalledStatus, Version: flag.TektonVersion}}
+	installed := map[string]string{flag.LabelInstance: config.Name}
+	kept := newConfigMap("config-defaults", namespace, installed)
+	deleted := newConfigMap("config-logging", namespace, installed)
+
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*kept, *deleted}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+	r.instanceFor(config.Name).pipeline = m
+	assertNoEror(r.recordInventory(config, componentPipeline, m), "failed to record the inventory;", t)
+
+	// the user edits of the object that did not drift are kept
+	live := &corev1.ConfigMap{}
+	assertNoEror(runtime.DefaultUnstructuredConverter.FromUnstructured(kept.Object, live), "failed to convert config map;", t)
+	live.Data = map[string]string{"default-timeout-minutes": "120"}
+	assertNoEror(cl.Create(context.TODO(), live), "failed to create config map;", t)
+
+	_, err = r.validateVersion(newRequest(flag.ResourceWatched, ""), config)
+	assertNoEror(err, "failed to validate the version;", t)
+
+	key := types.NamespacedName{Namespace: namespace, Name: deleted.GetName()}
+	assertNoEror(cl.Get(context.TODO(), key, &corev1.ConfigMap{}), "expected the deleted config map to be restored;", t)
+	key.Name = kept.GetName()
+	assertNoEror(cl.Get(context.TODO(), key, live), "failed to get config map;", t)
+	if live.Data["default-timeout-minutes"] != "120" {
+		t.Errorf("expected %s not to be applied again, got %v", kept.GetName(), live.Data)
+	}
+	if code := config.Status.Conditions[0].Code; code != op.InstalledStatus {
+		t.Errorf("expected the install to be kept, got status %s", code)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/pkg/utils/schedule"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// timeNow is replaced in tests
+var timeNow = time.Now
+
+// maintenanceWindow parses the maintenance window of cfg, nil when it has none
+func maintenanceWindow(cfg *op.Config) (*schedule.Window, error) {
+	w := cfg.Spec.MaintenanceWindow
+	if w == nil {
+		return nil, nil
+	}
+	return schedule.NewWindow(w.Schedule, w.Duration.Duration)
+}
+
+// deferUpgrade records the upgrade of cfg to the releases of the operator as
+// pending and requeues it at the start of the next maintenance window. It
+// reports whether the upgrade was deferred: upgrades run right away when cfg
+// has no window, the window is open or the force-upgrade annotation is set
+func (r *ReconcileConfig) deferUpgrade(req reconcile.Request, cfg *op.Config) (reconcile.Result, bool, error) {
+	log := requestLogger(req, "maintenance-window")
+
+	window, err := maintenanceWindow(cfg)
+	if err != nil || window == nil {
+		return reconcile.Result{}, false, err
+	}
+
+	if _, forced := cfg.Annotations[flag.AnnotationForceUpgrade]; forced {
+		log.Info("upgrade forced outside of the maintenance window")
+		delete(cfg.Annotations, flag.AnnotationForceUpgrade)
+		if err := r.client.Update(context.TODO(), cfg); err != nil {
+			log.Error(err, "failed to remove force-upgrade annotation")
+			return reconcile.Result{}, true, err
+		}
+		return reconcile.Result{}, false, nil
+	}
+
+	now := timeNow()
+	if window.Open(now) {
+		log.Info("upgrading in the maintenance window")
+		return reconcile.Result{}, false, nil
+	}
+
+	next := window.NextOpen(now)
+	inst := r.instanceFor(cfg.Name)
+	latest := cfg.Status.Conditions[0]
+	details := fmt.Sprintf("upgrade to operator %s, pipeline %s and triggers %s waits for the maintenance window at %s",
+		flag.TektonVersion, inst.pipelineVersion, inst.triggersVersion, next.Format(time.RFC3339))
+	if latest.Code != op.UpgradePending || latest.Details != details {
+		log.Info("upgrade pending", "window", next)
+		// the condition keeps the installed versions
+		pipeline, triggers := installedVersions(cfg)
+		err = r.updateStatus(cfg, op.ConfigCondition{
+			Code:            op.UpgradePending,
+			Details:         details,
+			Version:         latest.Version,
+			PipelineVersion: pipeline,
+			TriggersVersion: triggers,
+		})
+	}
+	return reconcile.Result{RequeueAfter: next.Sub(now)}, true, err
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestDeferUpgrade(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	at := func(hour int) func() time.Time {
+		return func() time.Time { return time.Date(2020, 11, 6, hour, 0, 0, 0, time.UTC) }
+	}
+
+	config := newConfig(flag.ResourceWatched, "")
+	config.Spec.TargetNamespace = "openshift-pipelines"
+	config.Spec.MaintenanceWindow = &op.MaintenanceWindow{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}}
+	config.Status.Conditions = []op.ConfigCondition{{
+		Code: op.InstalledStatus, Version: "1.0.0", PipelineVersion: "v0.17.0", TriggersVersion: "v0.8.1",
+	}}
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+	req := newRequest(flag.ResourceWatched, "")
+
+	// outside of the window the upgrade is pending until the window opens
+	timeNow = at(10)
+	result, deferred, err := r.deferUpgrade(req, config)
+	assertNoEror(err, "failed to defer upgrade;", t)
+	if !deferred || result.RequeueAfter != 12*time.Hour {
+		t.Fatalf("expected the upgrade to be deferred by 12h, got %v and %+v", deferred, result)
+	}
+	assertInstallStatus(t, config, op.UpgradePending)
+	pending := config.Status.Conditions[0]
+	if pending.Version != "1.0.0" || pending.PipelineVersion != "v0.17.0" || pending.TriggersVersion != "v0.8.1" {
+		t.Errorf("expected the installed versions to be kept, got %+v", pending)
+	}
+	if !strings.Contains(pending.Details, flag.TektonVersion) || !strings.Contains(pending.Details, "2020-11-06T22:00:00Z") {
+		t.Errorf("expected the target version and the window in the details, got %q", pending.Details)
+	}
+
+	// the status is not updated again for the same window
+	timeNow = at(11)
+	_, deferred, err = r.deferUpgrade(req, config)
+	assertNoEror(err, "failed to defer upgrade;", t)
+	if !deferred || config.Status.Conditions[0].Attempts != 1 {
+		t.Errorf("expected the pending upgrade to be recorded once, got %+v", config.Status.Conditions[0])
+	}
+
+	// inside of the window the upgrade runs
+	timeNow = at(23)
+	_, deferred, err = r.deferUpgrade(req, config)
+	assertNoEror(err, "failed to check the window;", t)
+	if deferred {
+		t.Errorf("expected the upgrade to run in the window")
+	}
+
+	// a forced upgrade runs right away, once
+	timeNow = at(10)
+	config.Annotations = map[string]string{flag.AnnotationForceUpgrade: "true"}
+	assertNoEror(cl.Update(context.TODO(), config), "failed to annotate config;", t)
+	_, deferred, err = r.deferUpgrade(req, config)
+	assertNoEror(err, "failed to force upgrade;", t)
+	if deferred {
+		t.Errorf("expected the forced upgrade to run outside of the window")
+	}
+	updated := &op.Config{}
+	assertNoEror(cl.Get(context.TODO(), types.NamespacedName{Name: flag.ResourceWatched}, updated), "failed to get config;", t)
+	if _, ok := updated.Annotations[flag.AnnotationForceUpgrade]; ok {
+		t.Errorf("expected the force-upgrade annotation to be removed")
+	}
+
+	// without a window upgrades run right away
+	config.Spec.MaintenanceWindow = nil
+	if _, deferred, _ := r.deferUpgrade(req, config); deferred {
+		t.Errorf("expected the upgrade to run without a window")
+	}
+}
+
+func TestReconcileInvalidMaintenanceWindow(t *testing.T) {
+	config := newConfig(flag.ResourceWatched, "")
+	config.Spec.TargetNamespace = "openshift-pipelines"
+	config.Spec.MaintenanceWindow = &op.MaintenanceWindow{Schedule: "0 22 * *", Duration: metav1.Duration{Duration: time.Hour}}
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+	_, err := r.Reconcile(newRequest(flag.ResourceWatched, ""))
+	assertNoEror(err, "failed to reconcile;", t)
+	updated := &op.Config{}
+	assertNoEror(cl.Get(context.TODO(), types.NamespacedName{Name: flag.ResourceWatched}, updated), "failed to get config;", t)
+	assertInstallStatus(t, updated, op.InvalidResource)
+}
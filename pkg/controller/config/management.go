@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reconcileManagementState acts on the management state of cfg and reports
+// whether the reconcile is done. Unmanaged components are left alone, Removed
+// ones are deleted once, and components managed again are re-applied so that
+// the changes made meanwhile are reverted
+func (r *ReconcileConfig) reconcileManagementState(req reconcile.Request, cfg *op.Config) (reconcile.Result, bool, error) {
+	log := requestLogger(req, "management-state")
+
+	state, previous := cfg.ManagementState(), cfg.Status.ManagementState
+	switch state {
+	case op.Unmanaged:
+		if previous != op.Unmanaged {
+			log.Info("leaving the components unmanaged")
+		}
+		return reconcile.Result{}, true, r.updateManagementState(cfg, state)
+
+	case op.Removed:
+		if previous == op.Removed {
+			return reconcile.Result{}, true, nil
+		}
+		// the CRDs are kept, deleting them would delete the pipelines, the
+		// runs and the other resources of the users with them
+		log.Info("removing the components")
+		if err := r.deleteComponents(req, crd); err != nil {
+			return reconcile.Result{}, true, err
+		}
+		inventory, err := r.readInventory(cfg.Name)
+		if err != nil {
+			return reconcile.Result{}, true, err
+		}
+		cfg.Status.ManagementState = op.Removed
+		cfg.Status.Inventory = inventoryStatus(inventory)
+		cfg.Status.UnmatchedOverlays = nil
+		err = r.updateStatus(cfg, op.ConfigCondition{
+			Code:    op.EmptyStatus,
+			Details: "components removed, managementState is Removed",
+			Version: flag.TektonVersion,
+		})
+		return reconcile.Result{}, true, err
+	}
+
+	if previous == op.Managed {
+		return reconcile.Result{}, false, nil
+	}
+	if previous == "" {
+		return reconcile.Result{}, false, r.updateManagementState(cfg, state)
+	}
+
+	// start over from the first phase, like a reconcile request
+	log.Info("managing the components again", "previous", previous)
+	cfg.Status.ManagementState = state
+	err := r.updateStatus(cfg, op.ConfigCondition{
+		Code:    op.EmptyStatus,
+		Details: "managementState changed from " + string(previous) + " to " + string(state),
+		Version: flag.TektonVersion,
+	})
+	return reconcile.Result{Requeue: true}, true, err
+}
+
+// crd matches the CustomResourceDefinitions of the components
+var crd = mf.ByKind("CustomResourceDefinition")
+
+// updateManagementState records state in the status of cfg, if it changed
+func (r *ReconcileConfig) updateManagementState(cfg *op.Config, state op.ManagementState) error {
+	if cfg.Status.ManagementState == state {
+		return nil
+	}
+	tmp := cfg.DeepCopy()
+	tmp.Status.ManagementState = state
+	if err := r.client.Status().Update(context.TODO(), tmp); err != nil {
+		ctrlLog.Error(err, "status update failed")
+		return err
+	}
+	return r.refreshCR(cfg)
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestReconcileManagementState(t *testing.T) {
+	const namespace = "openshift-pipelines"
+	config := newConfig(flag.ResourceWatched, "")
+	config.Spec.TargetNamespace = namespace
+	config.Spec.ManagementState = op.Unmanaged
+	config.Status.Conditions = []op.ConfigCondition{{Code: op.InstalledStatus, Version: flag.TektonVersion}}
+	cl := feedConfigMock(config)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+	req := newRequest(flag.ResourceWatched, "")
+	key := types.NamespacedName{Name: flag.ResourceWatched}
+
+	result, err := r.Reconcile(req)
+	assertNoEror(err, "failed to reconcile unmanaged config;", t)
+	if result.Requeue {
+		t.Errorf("expected an unmanaged config not to requeue")
+	}
+	assertNoEror(cl.Get(context.TODO(), key, config), "failed to get config;", t)
+	assertInstallStatus(t, config, op.InstalledStatus)
+	assertManagementState(t, config, op.Unmanaged)
+
+	// managed again, every component is applied again
+	config.Spec.ManagementState = op.Managed
+	assertNoEror(cl.Update(context.TODO(), config), "failed to update config;", t)
+	result, err = r.Reconcile(req)
+	assertNoEror(err, "failed to reconcile managed config;", t)
+	if !result.Requeue {
+		t.Errorf("expected a config managed again to requeue")
+	}
+	assertNoEror(cl.Get(context.TODO(), key, config), "failed to get config;", t)
+	assertInstallStatus(t, config, op.EmptyStatus)
+	assertManagementState(t, config, op.Managed)
+
+	// removed, the installed objects but the CRDs are deleted once
+	cm := newConfigMap("config-logging", namespace, map[string]string{flag.LabelInstance: config.Name})
+	crd := &unstructured.Unstructured{}
+	crd.SetAPIVersion("apiextensions.k8s.io/v1beta1")
+	crd.SetKind("CustomResourceDefinition")
+	crd.SetName("pipelineruns.tekton.dev")
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*cm, *crd}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+	r.instanceFor(config.Name).pipeline = m
+	assertNoEror(r.recordInventory(config, componentPipeline, m), "failed to record the inventory;", t)
+	typed := &corev1.ConfigMap{}
+	assertNoEror(runtime.DefaultUnstructuredConverter.FromUnstructured(cm.Object, typed), "failed to convert config map;", t)
+	assertNoEror(cl.Create(context.TODO(), typed), "failed to create config map;", t)
+
+	assertNoEror(cl.Get(context.TODO(), key, config), "failed to get config;", t)
+	config.Spec.ManagementState = op.Removed
+	assertNoEror(cl.Update(context.TODO(), config), "failed to update config;", t)
+	_, err = r.Reconcile(req)
+	assertNoEror(err, "failed to reconcile removed config;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: cm.GetName()}, &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected %s to be deleted, got %v", cm.GetName(), err)
+	}
+	config = &op.Config{}
+	assertNoEror(cl.Get(context.TODO(), key, config), "failed to get config;", t)
+	assertInstallStatus(t, config, op.EmptyStatus)
+	assertManagementState(t, config, op.Removed)
+	inventory, err := r.readInventory(config.Name)
+	assertNoEror(err, "failed to read the inventory;", t)
+	if entries := inventory[componentPipeline]; len(entries) != 1 || entries[0].Name != crd.GetName() {
+		t.Errorf("expected only the CRD left in the inventory, got %+v", inventory)
+	}
+	if status := config.Status.Inventory; len(status) != 1 || status[0].Count != 1 {
+		t.Errorf("expected the CRD left in the status inventory, got %+v", status)
+	}
+
+	conditions := len(config.Status.Conditions)
+	_, err = r.Reconcile(req)
+	assertNoEror(err, "failed to reconcile removed config;", t)
+	assertNoEror(cl.Get(context.TODO(), key, config), "failed to get config;", t)
+	if len(config.Status.Conditions) != conditions {
+		t.Errorf("expected a removed config to be left alone, got conditions %+v", config.Status.Conditions)
+	}
+}
+
+func assertManagementState(t *testing.T, cfg *op.Config, state op.ManagementState) {
+	t.Helper()
+
+	if cfg.Status.ManagementState != state {
+		t.Fatalf("expected management state %s, got %s", state, cfg.Status.ManagementState)
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/utils/transform"
+)
+
+// unmatchedOverlays returns the targets of the overlays of cfg that match no
+// object of the installed manifests, e.g. once a release drops the object
+func unmatchedOverlays(cfg *op.Config, manifests ...mf.Manifest) []string {
+	var unmatched []string
+	for i, o := range cfg.Spec.Overlays {
+		if !overlayMatches(o.Target, manifests) {
+			unmatched = append(unmatched, fmt.Sprintf("overlays[%d]: %s", i, describeTarget(o.Target)))
+		}
+	}
+	return unmatched
+}
+
+func overlayMatches(target op.OverlayTarget, manifests []mf.Manifest) bool {
+	for _, m := range manifests {
+		for _, u := range m.Resources() {
+			if transform.OverlayTargets(target, &u) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describeTarget(target op.OverlayTarget) string {
+	kind := target.Kind
+	if target.APIVersion != "" {
+		kind = target.APIVersion + " " + kind
+	}
+	if target.Namespace == "" {
+		return kind + " " + target.Name
+	}
+	return kind + " " + target.Namespace + "/" + target.Name
+}
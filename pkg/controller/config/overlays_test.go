@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestOverlays(t *testing.T) {
+	config := newConfig(flag.ResourceWatched, "openshift-pipelines")
+	config.Spec.Overlays = []op.Overlay{
+		{
+			Target: op.OverlayTarget{Kind: "ConfigMap", Namespace: "openshift-pipelines", Name: "config-logging"},
+			Patch:  "data:\n  loglevel.controller: debug\n",
+		},
+		{
+			Target: op.OverlayTarget{APIVersion: "v1", Kind: "Service", Name: "tekton-pipelines-webhook"},
+			Type:   op.JSONPatchOverlay,
+			Patch:  `[{"op": "add", "path": "/metadata/annotations", "value": {"team": "ci"}}]`,
+		},
+	}
+	cl := feedConfigMock(config)
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*newConfigMap("config-logging", "", nil)}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+
+	// the overlays patch the objects once they are in the target namespace
+	m, err = transformManifest(config, &m, componentPipeline)
+	assertNoEror(err, "failed to transform manifest;", t)
+	level, _, _ := unstructured.NestedString(m.Resources()[0].Object, "data", "loglevel.controller")
+	if level != "debug" {
+		t.Errorf("expected the overlay to set the log level, got %q", level)
+	}
+
+	unmatched := unmatchedOverlays(config, m)
+	if want := []string{"overlays[1]: v1 Service tekton-pipelines-webhook"}; !reflect.DeepEqual(unmatched, want) {
+		t.Errorf("expected unmatched overlays %v, got %v", want, unmatched)
+	}
+}
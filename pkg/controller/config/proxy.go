@@ -0,0 +1,241 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/pkg/utils/transform"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var proxyGVK = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "Proxy"}
+
+// proxySettings are the proxy and CA bundle the controllers of a Config use
+type proxySettings struct {
+	httpProxy  string
+	httpsProxy string
+	noProxy    string
+
+	// trustedCA is the bundle set in the spec; when it is empty the bundle of
+	// the cluster is injected into the ConfigMap on OpenShift
+	trustedCA string
+
+	// hash identifies the settings and the bundle published in the target
+	// namespace
+	hash string
+}
+
+// proxyFor returns the cluster wide proxy configuration, or the proxy of the
+// spec of cfg when the cluster has none
+func (r *ReconcileConfig) proxyFor(cfg *op.Config) (proxySettings, error) {
+	settings := proxySettings{}
+
+	proxy := &unstructured.Unstructured{}
+	proxy.SetGroupVersionKind(proxyGVK)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: flag.ClusterProxyName}, proxy)
+	switch {
+	case err == nil:
+		settings.httpProxy, _, _ = unstructured.NestedString(proxy.Object, "status", "httpProxy")
+		settings.httpsProxy, _, _ = unstructured.NestedString(proxy.Object, "status", "httpsProxy")
+		settings.noProxy, _, _ = unstructured.NestedString(proxy.Object, "status", "noProxy")
+	case apierrors.IsNotFound(err) || meta.IsNoMatchError(err):
+	default:
+		return settings, err
+	}
+
+	if spec := cfg.Spec.Proxy; spec != nil && settings.httpProxy == "" && settings.httpsProxy == "" {
+		settings.httpProxy = spec.HTTPProxy
+		settings.httpsProxy = spec.HTTPSProxy
+		settings.noProxy = spec.NoProxy
+	}
+	if spec := cfg.Spec.Proxy; spec != nil && spec.TrustedCA != "" {
+		settings.trustedCA = spec.TrustedCA
+	}
+
+	bundle, err := r.publishedCABundle(cfg)
+	if err != nil {
+		return settings, err
+	}
+	sum := sha256.Sum256([]byte(settings.httpProxy + "\n" + settings.httpsProxy + "\n" + settings.noProxy + "\n" +
+		settings.trustedCA + "\n" + bundle))
+	settings.hash = fmt.Sprintf("%x", sum[:8])
+	return settings, nil
+}
+
+// publishedCABundle returns the CA bundle in the trusted CA ConfigMap of the
+// target namespace of cfg
+func (r *ReconcileConfig) publishedCABundle(cfg *op.Config) (string, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.reader().Get(context.TODO(), types.NamespacedName{Name: flag.TrustedCAConfigMap, Namespace: cfg.Spec.TargetNamespace}, cm)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return cm.Data[flag.TrustedCAKey], nil
+}
+
+// env returns the proxy env vars, empty when no proxy is configured
+func (p proxySettings) env() []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", p.httpProxy},
+		{"HTTPS_PROXY", p.httpsProxy},
+		{"NO_PROXY", p.noProxy},
+	} {
+		if v.value != "" {
+			env = append(env, corev1.EnvVar{Name: v.name, Value: v.value})
+		}
+	}
+	return env
+}
+
+// proxyTransformers returns the transformers injecting the proxy settings and
+// the trusted CA bundle into the controllers
+func proxyTransformers(p proxySettings) []mf.Transformer {
+	return []mf.Transformer{transform.DeploymentProxy(p.env(), &transform.TrustedCA{
+		ConfigMap:      flag.TrustedCAConfigMap,
+		Key:            flag.TrustedCAKey,
+		MountPath:      flag.TrustedCAMountPath,
+		CertDirs:       flag.DefaultSSLCertDirs,
+		HashAnnotation: flag.AnnotationTrustedCAHash,
+		Hash:           p.hash,
+	})}
+}
+
+// withTrustedCA returns m with the ConfigMap publishing the trusted CA bundle.
+// On OpenShift the bundle of the cluster is injected into it, otherwise it
+// holds the bundle of the spec
+func withTrustedCA(m mf.Manifest, p proxySettings) (mf.Manifest, error) {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetName(flag.TrustedCAConfigMap)
+	if p.trustedCA != "" {
+		if err := unstructured.SetNestedField(u.Object, p.trustedCA, "data", flag.TrustedCAKey); err != nil {
+			return m, err
+		}
+	} else {
+		u.SetLabels(map[string]string{flag.LabelInjectTrustedCA: "true"})
+	}
+
+	published, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{u}), mf.UseClient(m.Client))
+	if err != nil {
+		return m, err
+	}
+	return m.Filter(mf.Not(mf.All(mf.ByKind("ConfigMap"), mf.ByName(flag.TrustedCAConfigMap)))).Append(published), nil
+}
+
+// proxied matches the objects that depend on the proxy settings and the
+// trusted CA bundle
+var proxied = mf.Any(
+	mf.ByKind("Deployment"),
+	mf.All(mf.ByKind("ConfigMap"), mf.ByName(flag.TrustedCAConfigMap)),
+)
+
+// applyProxy applies the Deployments and the trusted CA ConfigMap of an
+// installed Config again when the proxy settings or the trusted CA bundle
+// changed; the other objects do not depend on them
+func (r *ReconcileConfig) applyProxy(req reconcile.Request, cfg *op.Config) (reconcile.Result, error) {
+	log := requestLogger(req, "apply-proxy")
+	inst := r.instanceFor(cfg.Name)
+
+	pipeline, err := r.pipelineManifest(cfg)
+	if err != nil {
+		log.Error(err, "failed to apply manifest transformations on pipeline")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:    op.PipelineApplyError,
+			Version: flag.TektonVersion}, err)
+	}
+	if err := pipeline.Filter(proxied).Apply(); err != nil {
+		log.Error(err, "failed to apply the proxy settings to pipeline")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:    op.PipelineApplyError,
+			Version: flag.TektonVersion}, fmt.Errorf("failed to apply the proxy settings to pipeline: %w", err))
+	}
+	if err := r.recordInventory(cfg, componentPipeline, pipeline); err != nil {
+		log.Error(err, "failed to record the pipeline inventory")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:    op.PipelineApplyError,
+			Version: flag.TektonVersion}, fmt.Errorf("failed to record the pipeline inventory: %w", err))
+	}
+	inst.pipeline = pipeline
+
+	triggers, err := r.triggersManifest(cfg)
+	if err != nil {
+		log.Error(err, "failed to apply manifest transformations on triggers")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.TriggersError,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, err)
+	}
+	if err := triggers.Filter(proxied).Apply(); err != nil {
+		log.Error(err, "failed to apply the proxy settings to triggers")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.TriggersError,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to apply the proxy settings to triggers: %w", err))
+	}
+	if err := r.recordInventory(cfg, componentTriggers, triggers); err != nil {
+		log.Error(err, "failed to record the triggers inventory")
+		return r.retryOrFail(cfg, op.ConfigCondition{
+			Code:            op.TriggersError,
+			PipelineVersion: inst.pipelineVersion,
+			Version:         flag.TektonVersion}, fmt.Errorf("failed to record the triggers inventory: %w", err))
+	}
+	inst.triggers = triggers
+	log.Info("successfully applied the proxy settings")
+
+	tmp := cfg.DeepCopy()
+	tmp.Status.ProxyHash = inst.proxy.hash
+	if err := r.client.Status().Update(context.TODO(), tmp); err != nil {
+		log.Error(err, "status update failed")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, r.refreshCR(cfg)
+}
+
+// enqueueAllConfigs maps an event to a request for every Config, e.g. when
+// the cluster wide proxy configuration changes
+func enqueueAllConfigs(c client.Client) handler.ToRequestsFunc {
+	return func(handler.MapObject) []reconcile.Request {
+		configs := &op.ConfigList{}
+		if err := c.List(context.TODO(), configs); err != nil {
+			ctrlLog.Error(err, "failed to list config instances")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(configs.Items))
+		for _, cfg := range configs.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cfg.Name}})
+		}
+		return requests
+	}
+}
+
+// trustedCAChanged passes the updates and deletes of the ConfigMaps
+// publishing the trusted CA bundle
+var trustedCAChanged = predicate.Funcs{
+	CreateFunc: func(event.CreateEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.MetaNew.GetName() == flag.TrustedCAConfigMap
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return e.Meta.GetName() == flag.TrustedCAConfigMap
+	},
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
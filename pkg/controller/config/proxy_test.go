@@ -0,0 +1,150 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestProxyFor(t *testing.T) {
+	t.Run("cluster proxy", func(t *testing.T) {
+		config := newConfig("cluster", "openshift-pipelines")
+		config.Spec.Proxy = &op.ProxySpec{HTTPSProxy: "http://spec-proxy:3128"}
+		cl := feedConfigMock(config)
+		err := cl.Create(context.TODO(), newClusterProxy("http://cluster-proxy:3128", ".cluster.local"))
+		assertNoEror(err, "failed to create cluster proxy;", t)
+		r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+		proxy, err := r.proxyFor(config)
+		assertNoEror(err, "failed to read proxy;", t)
+		if proxy.httpsProxy != "http://cluster-proxy:3128" || proxy.noProxy != ".cluster.local" {
+			t.Errorf("expected the cluster proxy, got %+v", proxy)
+		}
+	})
+
+	t.Run("spec fallback", func(t *testing.T) {
+		config := newConfig("cluster", "openshift-pipelines")
+		config.Spec.Proxy = &op.ProxySpec{HTTPSProxy: "http://spec-proxy:3128", TrustedCA: "-----BEGIN CERTIFICATE-----"}
+		r := ReconcileConfig{scheme: scheme.Scheme, client: feedConfigMock(config)}
+
+		proxy, err := r.proxyFor(config)
+		assertNoEror(err, "failed to read proxy;", t)
+		if proxy.httpsProxy != "http://spec-proxy:3128" || proxy.trustedCA == "" {
+			t.Errorf("expected the proxy of the spec, got %+v", proxy)
+		}
+	})
+
+	t.Run("hash follows the published bundle", func(t *testing.T) {
+		config := newConfig("cluster", "openshift-pipelines")
+		cl := feedConfigMock(config)
+		r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+		before, err := r.proxyFor(config)
+		assertNoEror(err, "failed to read proxy;", t)
+
+		err = cl.Create(context.TODO(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: flag.TrustedCAConfigMap, Namespace: "openshift-pipelines"},
+			Data:       map[string]string{flag.TrustedCAKey: "-----BEGIN CERTIFICATE-----"},
+		})
+		assertNoEror(err, "failed to publish bundle;", t)
+		after, err := r.proxyFor(config)
+		assertNoEror(err, "failed to read proxy;", t)
+		if before.hash == after.hash {
+			t.Error("expected the hash to change with the published bundle")
+		}
+	})
+}
+
+func TestApplyPipelineProxy(t *testing.T) {
+	var (
+		configName = "cluster"
+		namespace  = "openshift-pipelines"
+	)
+
+	config := newConfig(configName, namespace)
+	cl := feedConfigMock(config)
+	pipelines, err := mfFor("pipelines", cl)
+	assertNoEror(err, "failed to create manifestival for pipelines;", t)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, pipeline: pipelines}
+	r.instanceFor(configName).proxy = proxySettings{httpsProxy: "http://proxy:3128", hash: "abc"}
+
+	_, err = r.applyPipeline(newRequest(configName, namespace), config)
+	assertNoEror(err, "failed to reconcile for applyPipeline;", t)
+
+	cm := &corev1.ConfigMap{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.TrustedCAConfigMap, Namespace: namespace}, cm)
+	assertNoEror(err, "failed to get trusted CA config map;", t)
+	if cm.Labels[flag.LabelInjectTrustedCA] != "true" {
+		t.Errorf("expected the cluster bundle to be injected, got labels %v", cm.Labels)
+	}
+
+	dep := &appsv1.Deployment{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineControllerName, Namespace: namespace}, dep)
+	assertNoEror(err, "failed to get pipeline controller;", t)
+	if dep.Spec.Template.Annotations[flag.AnnotationTrustedCAHash] != "abc" {
+		t.Errorf("expected trusted CA hash annotation, got %v", dep.Spec.Template.Annotations)
+	}
+	found := false
+	for _, env := range dep.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "HTTPS_PROXY" && env.Value == "http://proxy:3128" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected HTTPS_PROXY on the controller, got %v", dep.Spec.Template.Spec.Containers[0].Env)
+	}
+	if config.Status.ProxyHash != "abc" {
+		t.Errorf("expected proxy hash abc in status, got %s", config.Status.ProxyHash)
+	}
+}
+
+func TestProxyChangeAppliesDeploymentsOnly(t *testing.T) {
+	namespace := "openshift-pipelines"
+	config := newConfig(flag.ResourceWatched, namespace)
+	config.Status.ProxyHash = "old"
+	config.Status.Conditions = []op.ConfigCondition{{Code: op.InstalledStatus, Version: flag.TektonVersion}}
+	cl := feedConfigMock(config)
+	pipelines, err := mfFor("pipelines", cl)
+	assertNoEror(err, "failed to create manifestival for pipelines;", t)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl, pipeline: pipelines}
+	inst := r.instanceFor(config.Name)
+	inst.proxy = proxySettings{httpsProxy: "http://proxy:3128", hash: "abc"}
+	inst.driftChecked = timeNow()
+
+	_, err = r.validateVersion(newRequest(config.Name, ""), config)
+	assertNoEror(err, "failed to apply the proxy settings;", t)
+
+	dep := &appsv1.Deployment{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineControllerName, Namespace: namespace}, dep)
+	assertNoEror(err, "failed to get pipeline controller;", t)
+	if dep.Spec.Template.Annotations[flag.AnnotationTrustedCAHash] != "abc" {
+		t.Errorf("expected trusted CA hash annotation, got %v", dep.Spec.Template.Annotations)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.TrustedCAConfigMap, Namespace: namespace}, &corev1.ConfigMap{})
+	assertNoEror(err, "failed to get trusted CA config map;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineControllerName, Namespace: namespace}, &corev1.Service{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected only the deployments to be applied again, got service: %v", err)
+	}
+	assertInstallStatus(t, config, op.InstalledStatus)
+	if config.Status.ProxyHash != "abc" {
+		t.Errorf("expected proxy hash abc in status, got %s", config.Status.ProxyHash)
+	}
+}
+
+func newClusterProxy(httpsProxy, noProxy string) *unstructured.Unstructured {
+	proxy := &unstructured.Unstructured{}
+	proxy.SetGroupVersionKind(proxyGVK)
+	proxy.SetName(flag.ClusterProxyName)
+	_ = unstructured.SetNestedField(proxy.Object, httpsProxy, "status", "httpsProxy")
+	_ = unstructured.SetNestedField(proxy.Object, noProxy, "status", "noProxy")
+	return proxy
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/pkg/utils/transform"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// components of an install, set as the component label of their objects
+const (
+	componentPipeline     = "pipeline"
+	componentTriggers     = "triggers"
+	componentAddons       = "addons"
+	componentCommunity    = "community"
+	componentCLIDownloads = "cli-downloads"
+)
+
+var clusterTaskGVK = schema.GroupVersionKind{Group: "tekton.dev", Version: "v1beta1", Kind: "ClusterTask"}
+
+// legacyProviders are the provider types labelled on the cluster tasks of a
+// component by the operator versions that did not set the install labels yet
+var legacyProviders = map[string]string{
+	componentAddons:    flag.ProviderTypeRedHat,
+	componentCommunity: flag.ProviderTypeCommunity,
+}
+
+// installLabels returns the transformers setting the labels shared by the
+// objects installed for the component of cfg. The part-of label of the
+// upstream releases is kept
+func installLabels(cfg *op.Config, component string) []mf.Transformer {
+	return []mf.Transformer{
+		transform.InjectLabel(flag.LabelManagedBy, flag.ManagedBy, transform.Overwrite),
+		transform.InjectLabel(flag.LabelPartOf, flag.PartOf, transform.Retain),
+		transform.InjectLabel(flag.LabelComponent, component, transform.Overwrite),
+		transform.InjectLabel(flag.LabelInstallGeneration, installGeneration(cfg), transform.Overwrite),
+	}
+}
+
+// installGeneration hashes the operator version and the releases selected for
+// cfg, so that the labels only change on an upgrade
+func installGeneration(cfg *op.Config) string {
+	install := fmt.Sprintf("%s/%s/%s", flag.TektonVersion,
+		cfg.Status.DesiredPipelineVersion, cfg.Status.DesiredTriggersVersion)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(install)))[:16]
+}
+
+// prune deletes the objects labelled as installed for the component of cfg
+// that are not part of its manifest m anymore, e.g. the cluster tasks dropped
+// by a new release. Only the kinds of m and the extra kinds are looked at, and
+// CRDs are never pruned as that would delete the resources of the users.
+// The first time, the cluster tasks installed for cfg by the operator versions
+// that did not label them are adopted, so that the stale ones are pruned too
+func (r *ReconcileConfig) prune(cfg *op.Config, component string, m mf.Manifest, extra ...schema.GroupVersionKind) error {
+	current := map[string]bool{}
+	kinds := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range extra {
+		kinds[gvk] = true
+	}
+	for _, u := range m.Filter(mf.Not(mf.ByKind("CustomResourceDefinition"))).Resources() {
+		gk := u.GroupVersionKind().GroupKind().String()
+		current[gk+"/"+u.GetNamespace()+"/"+u.GetName()] = true
+		// cluster scoped objects have no namespace once they are created
+		current[gk+"//"+u.GetName()] = true
+		kinds[u.GroupVersionKind()] = true
+	}
+
+	installed := client.MatchingLabels{
+		flag.LabelInstance:  cfg.Name,
+		flag.LabelManagedBy: flag.ManagedBy,
+		flag.LabelComponent: component,
+	}
+	inst := r.instanceFor(cfg.Name)
+	for gvk := range kinds {
+		stale, err := r.listStale(gvk, current, installed)
+		if err != nil {
+			return err
+		}
+		if provider, ok := legacyProviders[component]; ok && gvk.GroupKind() == clusterTaskGVK.GroupKind() && !inst.adopted[component] {
+			legacy, err := labels.Parse(fmt.Sprintf("%s=%s,!%s", flag.LabelProviderType, provider, flag.LabelManagedBy))
+			if err != nil {
+				return err
+			}
+			adopted, err := r.listStale(gvk, current, client.MatchingLabelsSelector{Selector: legacy})
+			if err != nil {
+				return err
+			}
+			for _, u := range adopted {
+				if metav1.IsControlledBy(u, cfg) {
+					stale = append(stale, u)
+				}
+			}
+		}
+
+		for _, u := range stale {
+			ctrlLog.Info("pruning", "component", component, "kind", gvk.Kind, "namespace", u.GetNamespace(), "name", u.GetName())
+			if err := r.client.Delete(context.TODO(), u); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	if _, ok := legacyProviders[component]; ok {
+		if inst.adopted == nil {
+			inst.adopted = map[string]bool{}
+		}
+		inst.adopted[component] = true
+	}
+	return nil
+}
+
+// listStale returns the objects of kind gvk selected by opts that are not in
+// current. A kind that is not served is skipped
+func (r *ReconcileConfig) listStale(gvk schema.GroupVersionKind, current map[string]bool, opts ...client.ListOption) ([]*unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.reader().List(context.TODO(), list, opts...); err != nil {
+		if meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stale []*unstructured.Unstructured
+	for i := range list.Items {
+		u := &list.Items[i]
+		if !current[gvk.GroupKind().String()+"/"+u.GetNamespace()+"/"+u.GetName()] {
+			stale = append(stale, u)
+		}
+	}
+	return stale, nil
+}
+
+// reader returns the client reading the installed objects from the apiserver
+func (r *ReconcileConfig) reader() client.Reader {
+	if r.apiReader == nil {
+		return r.client
+	}
+	return r.apiReader
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestInstallLabels(t *testing.T) {
+	config := newConfig(flag.ResourceWatched, "openshift-pipelines")
+	cl := feedConfigMock(config)
+	upstream := map[string]string{flag.LabelPartOf: "tekton-pipelines", "app.kubernetes.io/component": "webhook"}
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{
+		*newConfigMap("config-logging", "", nil),
+		*newConfigMap("config-leader-election", "", upstream),
+	}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+
+	m, err = transformManifest(config, &m, componentPipeline)
+	assertNoEror(err, "failed to transform manifest;", t)
+	labels := m.Resources()[0].GetLabels()
+	for key, want := range map[string]string{
+		flag.LabelInstance:          config.Name,
+		flag.LabelManagedBy:         flag.ManagedBy,
+		flag.LabelPartOf:            flag.PartOf,
+		flag.LabelComponent:         componentPipeline,
+		flag.LabelInstallGeneration: installGeneration(config),
+	} {
+		if labels[key] != want {
+			t.Errorf("expected label %s=%s, got %q", key, want, labels[key])
+		}
+	}
+
+	// the labels the upstream webhooks select objects by are kept
+	labels = m.Resources()[1].GetLabels()
+	for key, want := range upstream {
+		if labels[key] != want {
+			t.Errorf("expected upstream label %s=%s to be kept, got %q", key, want, labels[key])
+		}
+	}
+	if labels[flag.LabelComponent] != componentPipeline {
+		t.Errorf("expected label %s=%s, got %q", flag.LabelComponent, componentPipeline, labels[flag.LabelComponent])
+	}
+
+	generation := installGeneration(config)
+	config.Generation++
+	if installGeneration(config) != generation {
+		t.Errorf("expected the install generation not to change with the generation of the config")
+	}
+	config.Status.DesiredPipelineVersion = "v0.17.3"
+	if installGeneration(config) == generation {
+		t.Errorf("expected a new install generation for pipeline %s", config.Status.DesiredPipelineVersion)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	// the fake client lists the kinds known to its scheme only
+	scheme.Scheme.AddKnownTypeWithName(consoleYAMLSampleGVK, &unstructured.Unstructured{})
+	scheme.Scheme.AddKnownTypeWithName(consoleYAMLSampleGVK.GroupVersion().WithKind("ConsoleYAMLSampleList"), &unstructured.UnstructuredList{})
+
+	const namespace = "openshift-pipelines"
+	config := newConfig(flag.ResourceWatched, namespace)
+	installed := func(component string) map[string]string {
+		return map[string]string{flag.LabelInstance: config.Name, flag.LabelManagedBy: flag.ManagedBy, flag.LabelComponent: component}
+	}
+	kept := newConfigMap("config-defaults", namespace, installed(componentPipeline))
+	dropped := newConfigMap("config-dropped", namespace, installed(componentPipeline))
+	triggers := newConfigMap("config-triggers", namespace, installed(componentTriggers))
+	user := newConfigMap("config-user", namespace, map[string]string{flag.LabelInstance: config.Name})
+	snippet := &unstructured.Unstructured{}
+	snippet.SetGroupVersionKind(consoleYAMLSampleGVK)
+	snippet.SetName("echo-snippet")
+	snippet.SetLabels(installed(componentCommunity))
+
+	cl := feedConfigMock(config)
+	for _, cm := range []*unstructured.Unstructured{kept, dropped, triggers, user} {
+		typed := &corev1.ConfigMap{}
+		assertNoEror(runtime.DefaultUnstructuredConverter.FromUnstructured(cm.Object, typed), "failed to convert config map;", t)
+		assertNoEror(cl.Create(context.TODO(), typed), "failed to create config map;", t)
+	}
+	assertNoEror(cl.Create(context.TODO(), snippet.DeepCopy()), "failed to create snippet;", t)
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+	pipeline, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*kept}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+	assertNoEror(r.prune(config, componentPipeline, pipeline), "failed to prune the pipeline;", t)
+	for _, cm := range []*unstructured.Unstructured{kept, triggers, user} {
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: cm.GetName()}, &corev1.ConfigMap{})
+		assertNoEror(err, "expected "+cm.GetName()+" to be kept;", t)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: dropped.GetName()}, &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected %s to be pruned, got %v", dropped.GetName(), err)
+	}
+
+	// the kinds of an empty manifest are given explicitly
+	assertNoEror(r.prune(config, componentCommunity, mf.Manifest{}, consoleYAMLSampleGVK), "failed to prune the community resources;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: snippet.GetName()}, snippet.DeepCopy())
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected %s to be pruned, got %v", snippet.GetName(), err)
+	}
+}
+
+func TestPruneAdoptsLegacyClusterTasks(t *testing.T) {
+	scheme.Scheme.AddKnownTypeWithName(clusterTaskGVK, &unstructured.Unstructured{})
+	scheme.Scheme.AddKnownTypeWithName(clusterTaskGVK.GroupVersion().WithKind("ClusterTaskList"), &unstructured.UnstructuredList{})
+
+	config := newConfig(flag.ResourceWatched, "openshift-pipelines")
+	config.UID = "cluster-uid"
+	owner := *metav1.NewControllerRef(config, op.SchemeGroupVersion.WithKind("Config"))
+	// cluster tasks installed before the install labels, owned by the config
+	legacy := func(name string, owners ...metav1.OwnerReference) *unstructured.Unstructured {
+		ct := &unstructured.Unstructured{}
+		ct.SetGroupVersionKind(clusterTaskGVK)
+		ct.SetName(name)
+		ct.SetLabels(map[string]string{flag.LabelProviderType: flag.ProviderTypeRedHat})
+		ct.SetOwnerReferences(owners)
+		return ct
+	}
+	current := legacy("git-clone", owner)
+	stale := legacy("git-clone-v0-16-3", owner)
+	user := legacy("user-task")
+
+	cl := feedConfigMock(config)
+	for _, ct := range []*unstructured.Unstructured{current, stale, user} {
+		assertNoEror(cl.Create(context.TODO(), ct.DeepCopy()), "failed to create cluster task;", t)
+	}
+	r := ReconcileConfig{scheme: scheme.Scheme, client: cl}
+
+	addons, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*current}), mf.UseClient(mfc.NewClient(cl)))
+	assertNoEror(err, "failed to create manifest;", t)
+	assertNoEror(r.prune(config, componentAddons, addons), "failed to prune the addons;", t)
+	for _, ct := range []*unstructured.Unstructured{current, user} {
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: ct.GetName()}, ct.DeepCopy())
+		assertNoEror(err, "expected "+ct.GetName()+" to be kept;", t)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: stale.GetName()}, stale.DeepCopy())
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected %s to be pruned, got %v", stale.GetName(), err)
+	}
+	if !r.instanceFor(config.Name).adopted[componentAddons] {
+		t.Errorf("expected the legacy cluster tasks of %s to be adopted once", componentAddons)
+	}
+}
+
+func newConfigMap(name, namespace string, labels map[string]string) *unstructured.Unstructured {
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetName(name)
+	cm.SetNamespace(namespace)
+	cm.SetLabels(labels)
+	return cm
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	pipelineReleaseLabel = "pipeline.tekton.dev/release"
+	triggersReleaseLabel = "triggers.tekton.dev/release"
+)
+
+// releases holds the payloads of a component bundled with the operator, keyed
+// by release version
+type releases map[string]mf.Manifest
+
+// readReleases reads every release payload under path. Each release lives in
+// a sub directory named after its version; a path without sub directories is
+// read as a single release whose version is taken from the controller label
+func readReleases(path string, controllerName string, labelName string, opts ...mf.Option) (releases, error) {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rels := releases{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		m, err := mf.ManifestFrom(sourceBasedOnRecursion(filepath.Join(path, entry.Name())), opts...)
+		if err != nil {
+			return nil, err
+		}
+		rels[canonicalVersion(entry.Name())] = m
+	}
+	if len(rels) > 0 {
+		return rels, nil
+	}
+
+	m, err := mf.ManifestFrom(sourceBasedOnRecursion(path), opts...)
+	if err != nil {
+		return nil, err
+	}
+	rels[canonicalVersion(getComponentVersion(m, controllerName, labelName))] = m
+	return rels, nil
+}
+
+// versions returns the bundled release versions, oldest first
+func (rels releases) versions() []string {
+	versions := make([]string, 0, len(rels))
+	for v := range rels {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+// latest returns the newest bundled release, which is installed when the
+// Config does not ask for a version
+func (rels releases) latest() mf.Manifest {
+	versions := rels.versions()
+	if len(versions) == 0 {
+		return mf.Manifest{}
+	}
+	return rels[versions[len(versions)-1]]
+}
+
+// selectRelease returns the release payload for the requested version, or
+// base when no version is requested
+func selectRelease(component string, rels releases, base mf.Manifest, requested string) (mf.Manifest, error) {
+	if requested == "" {
+		return base, nil
+	}
+	m, ok := rels[canonicalVersion(requested)]
+	if !ok {
+		return base, fmt.Errorf("%s version %s is not bundled with the operator, available versions: %s",
+			component, requested, strings.Join(rels.versions(), ", "))
+	}
+	return m, nil
+}
+
+// installedVersions returns the pipeline and triggers versions last reported
+// as installed in the status of cfg
+func installedVersions(cfg *op.Config) (pipeline string, triggers string) {
+	for _, c := range cfg.Status.Conditions {
+		if pipeline == "" {
+			pipeline = c.PipelineVersion
+		}
+		if triggers == "" {
+			triggers = c.TriggersVersion
+		}
+	}
+	return pipeline, triggers
+}
+
+// validUpgradePath returns an error when moving a component from the installed
+// version to the desired one is not supported. Upgrades are always allowed,
+// downgrades only to an earlier patch release of the same minor version since
+// the stored resources may not be readable by an older minor release
+func validUpgradePath(component, installed, desired string) error {
+	from, to := canonicalVersion(installed), canonicalVersion(desired)
+	if !semver.IsValid(from) || !semver.IsValid(to) {
+		return nil
+	}
+	if semver.Compare(to, from) >= 0 || semver.MajorMinor(to) == semver.MajorMinor(from) {
+		return nil
+	}
+	return fmt.Errorf("downgrading %s from %s to %s is not supported", component, installed, desired)
+}
+
+// canonicalVersion adds the "v" prefix used by the release labels
+func canonicalVersion(version string) string {
+	if version == "" || strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
+
+// selectReleases resolves the pipeline and triggers releases requested by the
+// spec of cfg, checks that they can be reached from the installed versions and
+// that the deployment overrides match them, and records them as the desired
+// versions in the status
+func (r *ReconcileConfig) selectReleases(cfg *op.Config) error {
+	pipeline, err := selectRelease("pipeline", r.pipelineReleases, r.pipeline, cfg.Spec.Pipeline.Version)
+	if err != nil {
+		return err
+	}
+	triggers, err := selectRelease("triggers", r.triggersReleases, r.triggers, cfg.Spec.Triggers.Version)
+	if err != nil {
+		return err
+	}
+
+	if err := unknownDeployments("pipeline", pipeline, cfg.Spec.Pipeline.Deployments); err != nil {
+		return err
+	}
+	if err := unknownDeployments("triggers", triggers, cfg.Spec.Triggers.Deployments); err != nil {
+		return err
+	}
+
+	desiredPipeline := getComponentVersion(pipeline, flag.PipelineControllerName, pipelineReleaseLabel)
+	desiredTriggers := getComponentVersion(triggers, flag.TriggerControllerName, triggersReleaseLabel)
+	installedPipeline, installedTriggers := installedVersions(cfg)
+	if err := validUpgradePath("pipeline", installedPipeline, desiredPipeline); err != nil {
+		return err
+	}
+	if err := validUpgradePath("triggers", installedTriggers, desiredTriggers); err != nil {
+		return err
+	}
+
+	inst := r.instanceFor(cfg.Name)
+	inst.pipelineRelease = pipeline
+	inst.triggersRelease = triggers
+	inst.pipelineVersion = desiredPipeline
+	inst.triggersVersion = desiredTriggers
+
+	cfg.Status.DesiredPipelineVersion = desiredPipeline
+	cfg.Status.DesiredTriggersVersion = desiredTriggers
+	return nil
+}
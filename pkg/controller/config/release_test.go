@@ -0,0 +1,110 @@
+package config
+
+import (
+	"path"
+	"path/filepath"
+	rt "runtime"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestReadReleases(t *testing.T) {
+	_, filename, _, _ := rt.Caller(0)
+	root := path.Join(path.Dir(filename), "../../..")
+
+	pipeline, err := readReleases(filepath.Join(root, flag.ResourceDir, "pipelines"),
+		flag.PipelineControllerName, pipelineReleaseLabel)
+	assertNoEror(err, "failed to read pipeline releases;", t)
+	if _, ok := pipeline["v0.18.0"]; !ok {
+		t.Errorf("expected pipeline release v0.18.0, got %v", pipeline.versions())
+	}
+
+	triggers, err := readReleases(filepath.Join(root, flag.ResourceDir, "triggers"),
+		flag.TriggerControllerName, triggersReleaseLabel)
+	assertNoEror(err, "failed to read triggers releases;", t)
+	if _, ok := triggers["v0.8.1"]; !ok {
+		t.Errorf("expected triggers release v0.8.1, got %v", triggers.versions())
+	}
+}
+
+func TestSelectRelease(t *testing.T) {
+	rels := releases{
+		"v0.17.3": releaseManifest(t, "v0.17.3"),
+		"v0.18.0": releaseManifest(t, "v0.18.0"),
+	}
+	if v := getComponentVersion(rels.latest(), flag.PipelineControllerName, pipelineReleaseLabel); v != "v0.18.0" {
+		t.Errorf("expected latest release v0.18.0, got %s", v)
+	}
+
+	m, err := selectRelease("pipeline", rels, rels.latest(), "0.17.3")
+	assertNoEror(err, "failed to select release;", t)
+	if v := getComponentVersion(m, flag.PipelineControllerName, pipelineReleaseLabel); v != "v0.17.3" {
+		t.Errorf("expected release v0.17.3, got %s", v)
+	}
+
+	if _, err := selectRelease("pipeline", rels, rels.latest(), "v0.99.0"); err == nil {
+		t.Error("expected an error for a release that is not bundled")
+	}
+}
+
+func TestValidUpgradePath(t *testing.T) {
+	tests := []struct {
+		installed, desired string
+		valid              bool
+	}{
+		{"", "v0.18.0", true},
+		{"v0.18.0", "v0.18.0", true},
+		{"v0.17.3", "v0.18.0", true},
+		{"v0.18.1", "v0.18.0", true},
+		{"v0.18.0", "v0.17.3", false},
+		{"devel", "v0.17.3", true},
+	}
+	for _, test := range tests {
+		err := validUpgradePath("pipeline", test.installed, test.desired)
+		if (err == nil) != test.valid {
+			t.Errorf("%s -> %s: expected valid %v, got %v", test.installed, test.desired, test.valid, err)
+		}
+	}
+}
+
+func TestSelectReleasesRejectsDowngrade(t *testing.T) {
+	config := newConfig("cluster", "openshift-pipelines")
+	config.Spec.Pipeline.Version = "v0.17.3"
+	config.Status.Conditions = []op.ConfigCondition{{
+		Code:            op.InstalledStatus,
+		PipelineVersion: "v0.18.0",
+	}}
+	rels := releases{
+		"v0.17.3": releaseManifest(t, "v0.17.3"),
+		"v0.18.0": releaseManifest(t, "v0.18.0"),
+	}
+	r := ReconcileConfig{scheme: scheme.Scheme, client: feedConfigMock(config),
+		pipeline: rels.latest(), pipelineReleases: rels}
+
+	if err := r.selectReleases(config); err == nil {
+		t.Fatal("expected downgrade across minor versions to be rejected")
+	}
+
+	config.Spec.Pipeline.Version = ""
+	assertNoEror(r.selectReleases(config), "failed to select default release;", t)
+	if config.Status.DesiredPipelineVersion != "v0.18.0" {
+		t.Errorf("expected desired pipeline version v0.18.0, got %s", config.Status.DesiredPipelineVersion)
+	}
+}
+
+func releaseManifest(t *testing.T, version string) mf.Manifest {
+	t.Helper()
+	controller := unstructured.Unstructured{}
+	controller.SetAPIVersion("apps/v1")
+	controller.SetKind("Deployment")
+	controller.SetName(flag.PipelineControllerName)
+	controller.SetLabels(map[string]string{pipelineReleaseLabel: version})
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{controller}))
+	assertNoEror(err, "failed to create release manifest;", t)
+	return m
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	mf "github.com/manifestival/manifestival"
+	paddons "github.com/tektoncd/operator/pkg/utils/addons"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const consoleYAMLSamplesCRD = "consoleyamlsamples.console.openshift.io"
+
+var consoleYAMLSampleGVK = schema.GroupVersionKind{Group: "console.openshift.io", Version: "v1", Kind: "ConsoleYAMLSample"}
+
+// withTaskSnippets returns m with a snippet for each of its tasks when the
+// cluster serves ConsoleYAMLSamples
+func (r *ReconcileConfig) withTaskSnippets(m mf.Manifest) (mf.Manifest, error) {
+	if !r.consoleSamples {
+		return m, nil
+	}
+	snippets, err := paddons.TaskSnippets(m)
+	if err != nil {
+		return m, err
+	}
+	return m.Append(snippets), nil
+}
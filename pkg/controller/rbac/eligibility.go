@@ -0,0 +1,146 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// eligibility decides in which namespaces the pipeline sa is set up
+type eligibility struct {
+	ignore  *regexp.Regexp
+	include labels.Selector
+	exclude labels.Selector
+}
+
+// newEligibility parses the ignore pattern and the include and exclude label
+// selectors. An empty include selector selects every namespace, an empty
+// exclude selector none
+func newEligibility(ignorePattern, includeSelector, excludeSelector string) (*eligibility, error) {
+	ignore, err := regexp.Compile(ignorePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore pattern %q: %v", ignorePattern, err)
+	}
+	include, err := labels.Parse(includeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include selector %q: %v", includeSelector, err)
+	}
+	exclude := labels.Nothing()
+	if excludeSelector != "" {
+		if exclude, err = labels.Parse(excludeSelector); err != nil {
+			return nil, fmt.Errorf("invalid exclude selector %q: %v", excludeSelector, err)
+		}
+	}
+	return &eligibility{ignore: ignore, include: include, exclude: exclude}, nil
+}
+
+// ignored is true for the namespaces that are never touched, by name
+func (e *eligibility) ignored(name string) bool {
+	return e.ignore.MatchString(name)
+}
+
+// eligible is true when the pipeline sa is set up in ns
+func (e *eligibility) eligible(ns *corev1.Namespace) bool {
+	if e.ignored(ns.Name) || ns.Annotations[flag.AnnotationOptOut] == "true" {
+		return false
+	}
+	set := labels.Set(ns.Labels)
+	return e.include.Matches(set) && !e.exclude.Matches(set)
+}
+
+// namespaceChanged passes the namespace events that may change whether a
+// namespace is eligible, or the scc of its pipeline sa. Namespaces have no
+// generation, so changes of the labels and the annotations read by the
+// controller are compared instead. Only the namespaces created after since
+// pass on creation, the others are reconciled by the resync
+func namespaceChanged(since time.Time) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return !e.Meta.GetCreationTimestamp().Time.Before(since)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !labels.Equals(e.MetaOld.GetLabels(), e.MetaNew.GetLabels()) {
+				return true
+			}
+			for _, key := range []string{flag.AnnotationOptOut, flag.AnnotationSCC} {
+				if e.MetaOld.GetAnnotations()[key] != e.MetaNew.GetAnnotations()[key] {
+					return true
+				}
+			}
+			return false
+		},
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return true },
+	}
+}
+
+// offboard removes the pipeline sa, its role bindings and the namespace policy
+// from a namespace that is no longer eligible. Only the sa created by the
+// operator is deleted; the sa is removed from the subjects of the bindings,
+// which are deleted once they have no subject left
+func (r *ReconcileRBAC) offboard(ns *corev1.Namespace) error {
+	log := ctrlLog.WithName("offboard").WithValues("ns", ns.Name)
+
+	for _, name := range append(managedRoleBindings, flag.PipelineAnyuid) {
+		if err := r.removeSubject(ns.Name, name); err != nil {
+			return err
+		}
+	}
+
+	sa := &corev1.ServiceAccount{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineSA, Namespace: ns.Name}, sa)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && sa.Labels[flag.LabelManagedBy] == flag.ManagedBy {
+		log.Info("deleting sa", "sa", sa.Name)
+		if err := ignoreNotFound(r.client.Delete(context.TODO(), sa)); err != nil {
+			return err
+		}
+	}
+
+	if err := r.ensureLimitRange(ns.Name, nil); err != nil {
+		return err
+	}
+	return r.recordStatus(ns, nil)
+}
+
+// removeSubject removes the pipeline sa from the subjects of the role binding
+func (r *ReconcileRBAC) removeSubject(namespace, name string) error {
+	log := ctrlLog.WithName("rb").WithValues("ns", namespace, "rb", name)
+
+	rb := &rbacv1.RoleBinding{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, rb)
+	if err != nil {
+		return ignoreNotFound(err)
+	}
+
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: flag.PipelineSA, Namespace: namespace}
+	if !hasSubject(rb.Subjects, subject) {
+		return nil
+	}
+	subjects := make([]rbacv1.Subject, 0, len(rb.Subjects))
+	for _, s := range rb.Subjects {
+		if !hasSubject([]rbacv1.Subject{s}, subject) {
+			subjects = append(subjects, s)
+		}
+	}
+
+	if len(subjects) == 0 {
+		log.Info("deleting rolebinding")
+		return ignoreNotFound(r.client.Delete(context.TODO(), rb))
+	}
+	log.Info("removing sa from rolebinding")
+	rb.Subjects = subjects
+	return r.client.Update(context.TODO(), rb)
+}
@@ -0,0 +1,134 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestEligible(t *testing.T) {
+	e, err := newEligibility(flag.DefaultIgnorePattern, "team", "tier=system")
+	assertNoError(err, "failed to parse namespace selection;", t)
+
+	for _, tc := range []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		eligible    bool
+	}{
+		{name: "dev", labels: map[string]string{"team": "a"}, eligible: true},
+		{name: "openshift-config", labels: map[string]string{"team": "a"}},
+		{name: "dev", labels: map[string]string{}},
+		{name: "dev", labels: map[string]string{"team": "a", "tier": "system"}},
+		{name: "dev", labels: map[string]string{"team": "a"}, annotations: map[string]string{flag.AnnotationOptOut: "true"}},
+	} {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels, Annotations: tc.annotations}}
+		if got := e.eligible(ns); got != tc.eligible {
+			t.Errorf("namespace %s with labels %v and annotations %v: expected eligible %t, got %t",
+				tc.name, tc.labels, tc.annotations, tc.eligible, got)
+		}
+	}
+
+	all, err := newEligibility(flag.DefaultIgnorePattern, "", "")
+	assertNoError(err, "failed to parse namespace selection;", t)
+	if !all.eligible(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}) {
+		t.Error("expected every namespace to be eligible without selectors")
+	}
+
+	if _, err := newEligibility(flag.DefaultIgnorePattern, "team in (", ""); err == nil {
+		t.Error("expected an invalid selector to fail")
+	}
+}
+
+func TestNamespaceChanged(t *testing.T) {
+	old := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"team": "a"}}}
+
+	relabelled := old.DeepCopy()
+	relabelled.Labels["tier"] = "system"
+	optedOut := old.DeepCopy()
+	optedOut.Annotations = map[string]string{flag.AnnotationOptOut: "true"}
+	annotated := old.DeepCopy()
+	annotated.Annotations = map[string]string{flag.AnnotationOnboardingStatus: "{}"}
+
+	for name, tc := range map[string]struct {
+		ns      *corev1.Namespace
+		changed bool
+	}{
+		"labels":            {relabelled, true},
+		"opt-out":           {optedOut, true},
+		"other annotations": {annotated, false},
+	} {
+		e := event.UpdateEvent{MetaOld: old, ObjectOld: old, MetaNew: tc.ns, ObjectNew: tc.ns}
+		if got := namespaceChanged(time.Time{}).Update(e); got != tc.changed {
+			t.Errorf("%s: expected %t, got %t", name, tc.changed, got)
+		}
+	}
+}
+
+func TestReconcileOffboardsIneligibleNamespace(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "dev",
+		Annotations: map[string]string{flag.AnnotationOptOut: "true", flag.AnnotationOnboardingStatus: "{}"},
+	}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:      flag.DefaultSA,
+		Namespace: ns.Name,
+		Labels:    map[string]string{flag.LabelManagedBy: flag.ManagedBy},
+	}}
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: flag.DefaultSA, Namespace: ns.Name}
+	edit := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: ns.Name},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}, subject},
+	}
+	anyuid := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid, Namespace: ns.Name},
+		Subjects:   []rbacv1.Subject{subject},
+	}
+	cl := newClient(ns, sa, edit, anyuid)
+	e, err := newEligibility(flag.DefaultIgnorePattern, "", "")
+	assertNoError(err, "failed to parse namespace selection;", t)
+	r := &ReconcileRBAC{client: cl, scheme: scheme.Scheme, namespaces: e}
+
+	_, err = r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+	assertNoError(err, "failed to reconcile namespace;", t)
+
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.DefaultSA, Namespace: ns.Name}, &corev1.ServiceAccount{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the sa to be deleted, got %v", err)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineAnyuid, Namespace: ns.Name}, &rbacv1.RoleBinding{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the %s rolebinding to be deleted, got %v", flag.PipelineAnyuid, err)
+	}
+	got := &rbacv1.RoleBinding{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: "edit", Namespace: ns.Name}, got), "failed to get edit rolebinding;", t)
+	if len(got.Subjects) != 1 || got.Subjects[0].Name != "alice" {
+		t.Errorf("expected only the other subjects of edit to be kept, got %v", got.Subjects)
+	}
+	offboarded := &corev1.Namespace{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: ns.Name}, offboarded), "failed to get namespace;", t)
+	if _, found := offboarded.Annotations[flag.AnnotationOnboardingStatus]; found {
+		t.Errorf("expected the onboarding status to be removed, got %v", offboarded.Annotations)
+	}
+}
+
+func TestOffboardKeepsUnmanagedSA(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultSA, Namespace: ns.Name}}
+	cl := newClient(ns, sa)
+	r := &ReconcileRBAC{client: cl, scheme: scheme.Scheme}
+
+	assertNoError(r.offboard(ns), "failed to offboard namespace;", t)
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: flag.DefaultSA, Namespace: ns.Name}, &corev1.ServiceAccount{})
+	assertNoError(err, "expected the sa not created by the operator to be kept;", t)
+}
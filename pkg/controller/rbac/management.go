@@ -0,0 +1,44 @@
+package rbac
+
+import (
+	"context"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// managementState returns the management state of the Config the operator
+// watches, Managed when it does not exist yet
+func (r *ReconcileRBAC) managementState() (op.ManagementState, error) {
+	cfg := &op.Config{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: flag.ResourceWatched}, cfg)
+	if errors.IsNotFound(err) {
+		return op.Managed, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return cfg.ManagementState(), nil
+}
+
+// managementStateChanged passes the events of the Config the operator watches
+// that change its management state
+func managementStateChanged() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			old, ok := e.ObjectOld.(*op.Config)
+			if !ok || e.MetaNew.GetName() != flag.ResourceWatched {
+				return false
+			}
+			cfg, ok := e.ObjectNew.(*op.Config)
+			return ok && old.ManagementState() != cfg.ManagementState()
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
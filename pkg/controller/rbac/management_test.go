@@ -0,0 +1,83 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileManagementState(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	cfg := &op.Config{
+		ObjectMeta: metav1.ObjectMeta{Name: flag.ResourceWatched},
+		Spec:       op.ConfigSpec{ManagementState: op.Unmanaged},
+	}
+	cl := newClient(ns, cfg, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "edit"}})
+	r := newTestReconciler(t, cl)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}}
+	saKey := types.NamespacedName{Name: flag.PipelineSA, Namespace: ns.Name}
+
+	_, err := r.Reconcile(req)
+	assertNoError(err, "failed to reconcile namespace;", t)
+	if err := cl.Get(context.TODO(), saKey, &corev1.ServiceAccount{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no sa while unmanaged, got %v", err)
+	}
+
+	setManagementState(t, r, op.Managed)
+	_, err = r.Reconcile(req)
+	assertNoError(err, "failed to reconcile namespace;", t)
+	assertNoError(cl.Get(context.TODO(), saKey, &corev1.ServiceAccount{}), "expected the sa once managed;", t)
+
+	setManagementState(t, r, op.Removed)
+	_, err = r.Reconcile(req)
+	assertNoError(err, "failed to reconcile namespace;", t)
+	if err := cl.Get(context.TODO(), saKey, &corev1.ServiceAccount{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the sa to be removed, got %v", err)
+	}
+}
+
+func TestManagementStateChanged(t *testing.T) {
+	p := managementStateChanged()
+	managed := &op.Config{ObjectMeta: metav1.ObjectMeta{Name: flag.ResourceWatched}}
+	unmanaged := managed.DeepCopy()
+	unmanaged.Spec.ManagementState = op.Unmanaged
+	explicit := managed.DeepCopy()
+	explicit.Spec.ManagementState = op.Managed
+	other := unmanaged.DeepCopy()
+	other.Name = "other"
+
+	tests := []struct {
+		name     string
+		old, new *op.Config
+		want     bool
+	}{
+		{"state changed", managed, unmanaged, true},
+		{"default made explicit", managed, explicit, false},
+		{"other config", managed.DeepCopy(), other, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := event.UpdateEvent{MetaOld: tt.old, ObjectOld: tt.old, MetaNew: tt.new, ObjectNew: tt.new}
+			if got := p.Update(e); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func setManagementState(t *testing.T, r *ReconcileRBAC, state op.ManagementState) {
+	t.Helper()
+	cfg := &op.Config{}
+	assertNoError(r.client.Get(context.TODO(), types.NamespacedName{Name: flag.ResourceWatched}, cfg), "failed to get config;", t)
+	cfg.Spec.ManagementState = state
+	assertNoError(r.client.Update(context.TODO(), cfg), "failed to update config;", t)
+}
@@ -0,0 +1,335 @@
+package rbac
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/yaml"
+)
+
+// keys of the policy ConfigMap
+const (
+	policyImagePullSecret = "imagePullSecret"
+	policyGitSecret       = "gitSecret"
+	policyLimitRange      = "limitRange"
+	policyPVCTemplates    = "pvcTemplates"
+)
+
+// namespacePolicy is what is set up in each namespace next to the pipeline
+// service account. It is declared by a ConfigMap in the operator namespace
+type namespacePolicy struct {
+	// imagePullSecret and gitSecret are linked to the service account when
+	// they exist in the namespace
+	imagePullSecret string
+	gitSecret       string
+
+	// limitRange is the spec of the LimitRange applied to the TaskRun pods
+	limitRange *corev1.LimitRangeSpec
+
+	// pvcTemplates are the workspace claims created in the namespace
+	pvcTemplates []corev1.PersistentVolumeClaim
+
+	// hash identifies the content of the policy
+	hash string
+}
+
+// onboardingStatus is recorded in an annotation of each namespace
+type onboardingStatus struct {
+	Policy         string   `json:"policy,omitempty"`
+	LinkedSecrets  []string `json:"linkedSecrets,omitempty"`
+	MissingSecrets []string `json:"missingSecrets,omitempty"`
+	LimitRange     string   `json:"limitRange,omitempty"`
+	PVCs           []string `json:"pvcs,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// readPolicy returns the namespace policy, or nil when none is declared
+func (r *ReconcileRBAC) readPolicy() (*namespacePolicy, error) {
+	if r.policyNamespace == "" || r.policyName == "" {
+		return nil, nil
+	}
+	cm := &corev1.ConfigMap{}
+	err := r.reader().Get(context.TODO(), types.NamespacedName{Name: r.policyName, Namespace: r.policyNamespace}, cm)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parsePolicy(cm)
+}
+
+// parsePolicy reads the namespace policy declared by cm
+func parsePolicy(cm *corev1.ConfigMap) (*namespacePolicy, error) {
+	policy := &namespacePolicy{
+		imagePullSecret: cm.Data[policyImagePullSecret],
+		gitSecret:       cm.Data[policyGitSecret],
+	}
+
+	if spec := cm.Data[policyLimitRange]; spec != "" {
+		policy.limitRange = &corev1.LimitRangeSpec{}
+		if err := yaml.UnmarshalStrict([]byte(spec), policy.limitRange); err != nil {
+			return nil, fmt.Errorf("invalid %s in policy %s: %v", policyLimitRange, cm.Name, err)
+		}
+	}
+	if templates := cm.Data[policyPVCTemplates]; templates != "" {
+		if err := yaml.UnmarshalStrict([]byte(templates), &policy.pvcTemplates); err != nil {
+			return nil, fmt.Errorf("invalid %s in policy %s: %v", policyPVCTemplates, cm.Name, err)
+		}
+		for _, pvc := range policy.pvcTemplates {
+			if pvc.Name == "" {
+				return nil, fmt.Errorf("invalid %s in policy %s: claim without a name", policyPVCTemplates, cm.Name)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(cm.Data))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s\n%s\n", k, cm.Data[k])
+	}
+	policy.hash = fmt.Sprintf("%x", h.Sum(nil)[:8])
+	return policy, nil
+}
+
+// onboard applies the namespace policy to ns and records the outcome in the
+// onboarding status annotation of ns
+func (r *ReconcileRBAC) onboard(ns *corev1.Namespace, sa *corev1.ServiceAccount) error {
+	policy, err := r.readPolicy()
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		if err := r.ensureLimitRange(ns.Name, nil); err != nil {
+			return err
+		}
+		return r.recordStatus(ns, nil)
+	}
+
+	status, err := r.applyPolicy(sa, policy)
+	if err != nil {
+		status.Error = err.Error()
+	}
+	if recErr := r.recordStatus(ns, status); recErr != nil {
+		return recErr
+	}
+	return err
+}
+
+// applyPolicy links the secrets of policy to sa and creates the LimitRange and
+// the workspace claims in its namespace
+func (r *ReconcileRBAC) applyPolicy(sa *corev1.ServiceAccount, policy *namespacePolicy) (*onboardingStatus, error) {
+	status := &onboardingStatus{Policy: policy.hash}
+
+	if err := r.linkSecrets(sa, policy, status); err != nil {
+		return status, err
+	}
+	if err := r.ensureLimitRange(sa.Namespace, policy.limitRange); err != nil {
+		return status, err
+	}
+	if policy.limitRange != nil {
+		status.LimitRange = flag.PipelineLimitRange
+	}
+	for _, tmpl := range policy.pvcTemplates {
+		if err := r.ensurePVC(sa.Namespace, tmpl); err != nil {
+			return status, err
+		}
+		status.PVCs = append(status.PVCs, tmpl.Name)
+	}
+	return status, nil
+}
+
+// linkSecrets adds the image pull secret to the image pull secrets of sa and
+// the git secret to its mountable secrets. Secrets missing from the namespace
+// are not linked, so that the pods of the service account still start
+func (r *ReconcileRBAC) linkSecrets(sa *corev1.ServiceAccount, policy *namespacePolicy, status *onboardingStatus) error {
+	log := ctrlLog.WithName("sa").WithValues("ns", sa.Namespace)
+
+	changed := false
+	for _, s := range []struct {
+		name string
+		pull bool
+	}{
+		{policy.imagePullSecret, true},
+		{policy.gitSecret, false},
+	} {
+		if s.name == "" {
+			continue
+		}
+		err := r.reader().Get(context.TODO(), types.NamespacedName{Name: s.name, Namespace: sa.Namespace}, &corev1.Secret{})
+		if errors.IsNotFound(err) {
+			status.MissingSecrets = append(status.MissingSecrets, s.name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		status.LinkedSecrets = append(status.LinkedSecrets, s.name)
+
+		if s.pull && !hasLocalReference(sa.ImagePullSecrets, s.name) {
+			sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: s.name})
+			changed = true
+		}
+		if !s.pull && !hasObjectReference(sa.Secrets, s.name) {
+			sa.Secrets = append(sa.Secrets, corev1.ObjectReference{Name: s.name})
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	log.Info("linking secrets", "secrets", status.LinkedSecrets)
+	return r.client.Update(context.TODO(), sa)
+}
+
+func hasLocalReference(refs []corev1.LocalObjectReference, name string) bool {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasObjectReference(refs []corev1.ObjectReference, name string) bool {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureLimitRange creates or updates the LimitRange of the namespace, and
+// deletes it when the policy declares none. A LimitRange of the same name
+// that was not created by the operator is left as it is
+func (r *ReconcileRBAC) ensureLimitRange(namespace string, spec *corev1.LimitRangeSpec) error {
+	log := ctrlLog.WithName("limitrange").WithValues("ns", namespace)
+
+	lr := &corev1.LimitRange{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineLimitRange, Namespace: namespace}, lr)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if exists && lr.Labels[flag.LabelManagedBy] != flag.ManagedBy {
+		if spec != nil {
+			log.Info("limit range not created by the operator, leaving it as it is")
+		}
+		return nil
+	}
+
+	switch {
+	case spec == nil && exists:
+		log.Info("deleting limit range")
+		return ignoreNotFound(r.client.Delete(context.TODO(), lr))
+	case spec == nil:
+		return nil
+	case !exists:
+		log.Info("creating limit range")
+		lr = &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      flag.PipelineLimitRange,
+				Namespace: namespace,
+				Labels:    map[string]string{flag.LabelManagedBy: flag.ManagedBy},
+			},
+			Spec: *spec.DeepCopy(),
+		}
+		return r.client.Create(context.TODO(), lr)
+	case !equality.Semantic.DeepEqual(lr.Spec, *spec):
+		log.Info("updating limit range")
+		lr.Spec = *spec.DeepCopy()
+		return r.client.Update(context.TODO(), lr)
+	}
+	return nil
+}
+
+// ensurePVC creates the claim of tmpl in the namespace. The spec of a claim
+// is immutable, so an existing claim is left as it is
+func (r *ReconcileRBAC) ensurePVC(namespace string, tmpl corev1.PersistentVolumeClaim) error {
+	err := r.reader().Get(context.TODO(), types.NamespacedName{Name: tmpl.Name, Namespace: namespace}, &corev1.PersistentVolumeClaim{})
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	ctrlLog.WithName("pvc").Info("creating workspace claim", "ns", namespace, "pvc", tmpl.Name)
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        tmpl.Name,
+			Namespace:   namespace,
+			Labels:      tmpl.Labels,
+			Annotations: tmpl.Annotations,
+		},
+		Spec: *tmpl.Spec.DeepCopy(),
+	}
+	return r.client.Create(context.TODO(), pvc)
+}
+
+// recordStatus sets the onboarding status annotation of ns to status, or
+// removes it when status is nil. The namespace is only updated on a change
+func (r *ReconcileRBAC) recordStatus(ns *corev1.Namespace, status *onboardingStatus) error {
+	annotations := ns.GetAnnotations()
+	current, found := annotations[flag.AnnotationOnboardingStatus]
+
+	if status == nil {
+		if !found {
+			return nil
+		}
+		delete(annotations, flag.AnnotationOnboardingStatus)
+		ns.SetAnnotations(annotations)
+		return r.client.Update(context.TODO(), ns)
+	}
+
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if found && current == string(value) {
+		return nil
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[flag.AnnotationOnboardingStatus] = string(value)
+	ns.SetAnnotations(annotations)
+	return r.client.Update(context.TODO(), ns)
+}
+
+// reader returns the client reading the Secrets and the claims from the
+// apiserver
+func (r *ReconcileRBAC) reader() client.Reader {
+	if r.apiReader == nil {
+		return r.client
+	}
+	return r.apiReader
+}
+
+// isPolicy passes the events of the ConfigMap declaring the namespace policy
+func isPolicy(namespace, name string) predicate.Funcs {
+	matches := func(m metav1.Object) bool {
+		return name != "" && m.GetNamespace() == namespace && m.GetName() == name
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return matches(e.Meta) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return matches(e.MetaNew) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return matches(e.Meta) },
+		GenericFunc: func(e event.GenericEvent) bool { return matches(e.Meta) },
+	}
+}
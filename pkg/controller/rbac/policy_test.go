@@ -0,0 +1,169 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const operatorNamespace = "openshift-operators"
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := parsePolicy(newPolicy(map[string]string{
+		policyImagePullSecret: "registry",
+		policyLimitRange:      "limits:\n- type: Container\n  default:\n    cpu: 500m\n",
+		policyPVCTemplates:    "- metadata:\n    name: source\n  spec:\n    accessModes: [ReadWriteOnce]\n",
+	}))
+	assertNoError(err, "failed to parse policy;", t)
+	if policy.imagePullSecret != "registry" || policy.limitRange == nil || len(policy.pvcTemplates) != 1 {
+		t.Errorf("unexpected policy %+v", policy)
+	}
+	if policy.limitRange.Limits[0].Default.Cpu().String() != "500m" {
+		t.Errorf("expected a default cpu of 500m, got %v", policy.limitRange.Limits[0].Default)
+	}
+
+	other, err := parsePolicy(newPolicy(map[string]string{policyImagePullSecret: "other"}))
+	assertNoError(err, "failed to parse policy;", t)
+	if other.hash == policy.hash {
+		t.Error("expected the hash to follow the content of the policy")
+	}
+
+	for name, data := range map[string]map[string]string{
+		"invalid limit range": {policyLimitRange: "limits: none"},
+		"unknown field":       {policyLimitRange: "limit: []"},
+		"unnamed claim":       {policyPVCTemplates: "- spec: {}"},
+	} {
+		if _, err := parsePolicy(newPolicy(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestOnboard(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultSA, Namespace: ns.Name}}
+	registry := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: ns.Name}}
+	policy := newPolicy(map[string]string{
+		policyImagePullSecret: "registry",
+		policyGitSecret:       "git",
+		policyLimitRange:      "limits:\n- type: Container\n  default:\n    memory: 512Mi\n",
+		policyPVCTemplates:    "- metadata:\n    name: source\n  spec:\n    accessModes: [ReadWriteOnce]\n",
+	})
+	cl := newClient(ns, sa, registry, policy)
+	r := &ReconcileRBAC{client: cl, scheme: scheme.Scheme, policyNamespace: operatorNamespace, policyName: flag.DefaultNamespacePolicy}
+
+	// applying the policy twice is idempotent
+	for i := 0; i < 2; i++ {
+		assertNoError(r.onboard(ns, sa), "failed to onboard namespace;", t)
+	}
+
+	got := &corev1.ServiceAccount{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.DefaultSA, Namespace: ns.Name}, got), "failed to get sa;", t)
+	if len(got.ImagePullSecrets) != 1 || got.ImagePullSecrets[0].Name != "registry" {
+		t.Errorf("expected the registry secret to be linked once, got %v", got.ImagePullSecrets)
+	}
+	if len(got.Secrets) != 0 {
+		t.Errorf("expected the missing git secret not to be linked, got %v", got.Secrets)
+	}
+
+	lr := &corev1.LimitRange{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineLimitRange, Namespace: ns.Name}, lr), "failed to get limit range;", t)
+	if lr.Labels[flag.LabelManagedBy] != flag.ManagedBy {
+		t.Errorf("expected the limit range to be labelled as managed, got %v", lr.Labels)
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: "source", Namespace: ns.Name}, pvc), "failed to get claim;", t)
+
+	status := onboardingStatusOf(t, cl, ns.Name)
+	if len(status.MissingSecrets) != 1 || status.MissingSecrets[0] != "git" || status.LimitRange != flag.PipelineLimitRange {
+		t.Errorf("unexpected onboarding status %+v", status)
+	}
+
+	// removing the policy removes the limit range and the status
+	assertNoError(cl.Delete(context.TODO(), policy), "failed to delete policy;", t)
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: ns.Name}, ns), "failed to get namespace;", t)
+	assertNoError(r.onboard(ns, got), "failed to onboard namespace;", t)
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineLimitRange, Namespace: ns.Name}, lr)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the limit range to be deleted, got %v", err)
+	}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: ns.Name}, ns), "failed to get namespace;", t)
+	if _, found := ns.Annotations[flag.AnnotationOnboardingStatus]; found {
+		t.Errorf("expected the onboarding status to be removed, got %v", ns.Annotations)
+	}
+}
+
+func TestOnboardKeepsUserLimitRange(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultSA, Namespace: ns.Name}}
+	user := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineLimitRange, Namespace: ns.Name},
+		Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{Type: corev1.LimitTypePod}}},
+	}
+	policy := newPolicy(map[string]string{
+		policyLimitRange: "limits:\n- type: Container\n  default:\n    memory: 512Mi\n",
+	})
+	cl := newClient(ns, sa, user, policy)
+	r := &ReconcileRBAC{client: cl, scheme: scheme.Scheme, policyNamespace: operatorNamespace, policyName: flag.DefaultNamespacePolicy}
+
+	assertNoError(r.onboard(ns, sa), "failed to onboard namespace;", t)
+	lr := &corev1.LimitRange{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineLimitRange, Namespace: ns.Name}, lr), "failed to get limit range;", t)
+	if lr.Spec.Limits[0].Type != corev1.LimitTypePod {
+		t.Errorf("expected the user limit range not to be updated, got %v", lr.Spec)
+	}
+
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: ns.Name}, ns), "failed to get namespace;", t)
+	assertNoError(r.offboard(ns), "failed to offboard namespace;", t)
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineLimitRange, Namespace: ns.Name}, lr), "expected the user limit range to be kept;", t)
+}
+
+func TestOnboardInvalidPolicy(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultSA, Namespace: ns.Name}}
+	cl := newClient(ns, sa, newPolicy(map[string]string{policyLimitRange: "limits: none"}))
+	r := &ReconcileRBAC{client: cl, scheme: scheme.Scheme, policyNamespace: operatorNamespace, policyName: flag.DefaultNamespacePolicy}
+
+	if err := r.onboard(ns, sa); err == nil {
+		t.Error("expected an invalid policy to fail")
+	}
+}
+
+func newPolicy(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultNamespacePolicy, Namespace: operatorNamespace},
+		Data:       data,
+	}
+}
+
+func newClient(objs ...runtime.Object) client.Client {
+	scheme.Scheme.AddKnownTypes(op.SchemeGroupVersion, &op.Config{}, &op.ConfigList{})
+	return fake.NewFakeClientWithScheme(scheme.Scheme, objs...)
+}
+
+func onboardingStatusOf(t *testing.T, cl client.Client, name string) onboardingStatus {
+	t.Helper()
+	ns := &corev1.Namespace{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: name}, ns), "failed to get namespace;", t)
+	status := onboardingStatus{}
+	assertNoError(json.Unmarshal([]byte(ns.Annotations[flag.AnnotationOnboardingStatus]), &status), "invalid onboarding status;", t)
+	return status
+}
+
+func assertNoError(err error, msg string, t *testing.T) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(msg, err)
+	}
+}
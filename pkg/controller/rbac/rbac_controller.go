@@ -2,17 +2,24 @@ package rbac
 
 import (
 	"context"
-	"regexp"
-
-	"github.com/operator-framework/operator-sdk/pkg/predicate"
+	"path/filepath"
+	"sync"
+	"time"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
 	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/pkg/utils/informer"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	k8s "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -29,39 +36,107 @@ var (
 // Add creates a new RBAC Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	r, err := newReconciler(mgr)
+	if err != nil {
+		ctrlLog.Error(err, "Namespace selection is invalid")
+		return err
+	}
+	return add(mgr, r)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	kc, _ := k8s.NewForConfig(mgr.GetConfig())
+func newReconciler(mgr manager.Manager) (*ReconcileRBAC, error) {
+	namespaces, err := newEligibility(flag.IgnorePattern, flag.IncludeNsSelector, flag.ExcludeNsSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	// the namespace policy is read from the operator namespace, it is not
+	// known when the operator runs locally
+	policyNamespace, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		ctrlLog.Info("namespace policy disabled, operator namespace unknown", "reason", err.Error())
+	}
+
+	scc, err := mf.ManifestFrom(mf.Recursive(filepath.Join(flag.ResourceDir, "scc")), mf.UseClient(mfc.NewClient(mgr.GetClient())))
+	if err != nil {
+		return nil, err
+	}
 
-	return &ReconcileRBAC{
-		client: mgr.GetClient(),
-		scheme: mgr.GetScheme(),
-		kc:     kc,
+	r := &ReconcileRBAC{
+		client:          mgr.GetClient(),
+		apiReader:       mgr.GetAPIReader(),
+		scheme:          mgr.GetScheme(),
+		policyNamespace: policyNamespace,
+		policyName:      flag.NamespacePolicy,
+		namespaces:      namespaces,
+		scc:             scc,
+		resync:          newResync(mgr.GetClient(), namespaces.ignored, flag.RBACResyncQPS, flag.RBACResyncPeriod),
 	}
+	// the cluster scoped objects are ensured again in every resync
+	r.resync.onPass = r.resetClusterScoped
+	// the legacy cluster role is only deleted once every namespace has been
+	// migrated
+	r.resync.onComplete = r.deleteLegacyClusterRole
+	return r, nil
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
-	if _, err := regexp.Compile(flag.IgnorePattern); err != nil {
-		ctrlLog.Error(err, "Ignore regex is invalid")
-		return err
-	}
+func add(mgr manager.Manager, r *ReconcileRBAC) error {
 	// Create a new controller
 	c, err := controller.New("rbac-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
 	}
 
+	// the namespaces that exist when the controller starts are reconciled
+	// by the initial resync, at a limited rate
 	err = c.Watch(
 		&source.Kind{Type: &corev1.Namespace{}},
 		&handler.EnqueueRequestForObject{},
-		predicate.GenerationChangedPredicate{},
+		namespaceChanged(time.Now()),
 	)
+	if err != nil {
+		return err
+	}
+	err = c.Watch(&source.Channel{Source: r.resync.events}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(r.resync); err != nil {
+		return err
+	}
 
-	return err
+	// restore the sa and the role bindings when they are changed or deleted
+	err = c.Watch(&source.Kind{Type: &corev1.ServiceAccount{}}, enqueueNamespace, isManaged(flag.PipelineSA))
+	if err != nil {
+		return err
+	}
+	err = c.Watch(&source.Kind{Type: &rbacv1.RoleBinding{}}, enqueueNamespace, isManaged(managedRoleBindings...))
+	if err != nil {
+		return err
+	}
+
+	// apply a changed management state to every namespace
+	err = c.Watch(
+		&source.Kind{Type: &op.Config{}},
+		resyncOnEvent(r.resync),
+		managementStateChanged(),
+	)
+	if err != nil {
+		return err
+	}
+
+	// apply a changed namespace policy to every namespace
+	policy, err := informer.ConfigMaps(mgr, r.policyNamespace, r.policyName)
+	if err != nil {
+		return err
+	}
+	return c.Watch(
+		policy,
+		resyncOnEvent(r.resync),
+		isPolicy(r.policyNamespace, r.policyName),
+	)
 }
 
 // blank assignment to verify that ReconcileRBAC implements reconcile.Reconciler
@@ -73,7 +148,30 @@ type ReconcileRBAC struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
-	kc     *k8s.Clientset
+
+	// apiReader reads the Secrets, the namespace policy and its claims from
+	// the apiserver, so that the cache does not watch every Secret and
+	// ConfigMap
+	apiReader client.Reader
+
+	// policyNamespace and policyName locate the ConfigMap declaring the
+	// namespace policy
+	policyNamespace string
+	policyName      string
+
+	// namespaces decides in which namespaces the sa is set up
+	namespaces *eligibility
+
+	// scc holds the security context constraints granted to the pipeline sa
+	scc mf.Manifest
+
+	// resync reconciles every namespace periodically
+	resync *resync
+
+	// clusterScoped is true once the cluster scoped objects shared by the
+	// namespaces have been ensured
+	clusterScopedMu sync.Mutex
+	clusterScoped   bool
 }
 
 func ignoreNotFound(err error) error {
@@ -89,39 +187,104 @@ func ignoreNotFound(err error) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileRBAC) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	res, err := r.reconcile(req)
+	if err == nil {
+		r.resync.reconciled(req.Name)
+	}
+	return res, err
+}
+
+func (r *ReconcileRBAC) reconcile(req reconcile.Request) (reconcile.Result, error) {
 	log := ctrlLog.WithValues("req.name", req.Name)
 
-	if ignore, _ := regexp.MatchString(flag.IgnorePattern, req.Name); ignore {
+	if r.namespaces.ignored(req.Name) {
 		return reconcile.Result{}, nil
 	}
 
-	log.Info("reconciling rbac sa")
-
 	ns, err := r.getNS(req)
 	if err != nil {
 		return reconcile.Result{}, ignoreNotFound(err)
 	}
 
-	sa, err := r.ensureSA(ns)
+	state, err := r.managementState()
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	switch state {
+	case op.Unmanaged:
+		log.Info("rbac sa unmanaged, leaving it as it is")
+		return reconcile.Result{}, nil
+	case op.Removed:
+		log.Info("components removed, removing rbac sa")
+		return reconcile.Result{}, r.offboard(ns)
+	}
 
-	// Maintaining a separate cluster role for the scc declaration.
-	// to assist us in managing this the scc association in a
-	// granular way.
-	err = r.ensureSCClusterRole()
+	if !r.namespaces.eligible(ns) {
+		log.Info("namespace not eligible, removing rbac sa")
+		return reconcile.Result{}, r.offboard(ns)
+	}
+
+	log.Info("reconciling rbac sa")
+
+	err = r.ensureClusterScoped()
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	err = r.ensureSCCRoleBinding(sa)
+
+	sa, err := r.ensureSA(ns)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.ensureSCCRoleBinding(ns, sa)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	err = r.ensureRoleBindings(sa)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.onboard(ns, sa)
 	return reconcile.Result{}, err
+}
+
+// ensureClusterScoped ensures the cluster scoped objects the namespaces share,
+// once and again after resetClusterScoped
+func (r *ReconcileRBAC) ensureClusterScoped() error {
+	r.clusterScopedMu.Lock()
+	defer r.clusterScopedMu.Unlock()
+	if r.clusterScoped {
+		return nil
+	}
+
+	log := ctrlLog.WithName("cluster")
+	log.Info("finding cluster role edit")
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: "edit"}, &rbacv1.ClusterRole{}); err != nil {
+		log.Error(err, "finding edit cluster role failed")
+		return err
+	}
+
+	// Maintaining a separate cluster role for the scc declaration.
+	// to assist us in managing this the scc association in a
+	// granular way.
+	if err := r.ensureSCC(); err != nil {
+		return err
+	}
+	if err := r.ensureSCClusterRole(); err != nil {
+		return err
+	}
+	r.clusterScoped = true
+	return nil
+}
 
+// resetClusterScoped makes the next reconcile ensure the cluster scoped
+// objects again
+func (r *ReconcileRBAC) resetClusterScoped() {
+	r.clusterScopedMu.Lock()
+	defer r.clusterScopedMu.Unlock()
+	r.clusterScoped = false
 }
 
 func (r *ReconcileRBAC) getNS(req reconcile.Request) (*corev1.Namespace, error) {
@@ -144,7 +307,7 @@ func (r *ReconcileRBAC) ensureSA(ns *corev1.Namespace) (*corev1.ServiceAccount,
 	sa := &corev1.ServiceAccount{}
 	saType := types.NamespacedName{Name: flag.PipelineSA, Namespace: ns.Name}
 	if err := r.client.Get(context.TODO(), saType, sa); err == nil {
-		return sa, err
+		return sa, r.adoptSA(sa)
 	} else if !errors.IsNotFound(err) {
 		return nil, err
 	}
@@ -155,6 +318,7 @@ func (r *ReconcileRBAC) ensureSA(ns *corev1.Namespace) (*corev1.ServiceAccount,
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      flag.PipelineSA,
 			Namespace: ns.Name,
+			Labels:    map[string]string{flag.LabelManagedBy: flag.ManagedBy},
 		},
 	}
 
@@ -162,23 +326,32 @@ func (r *ReconcileRBAC) ensureSA(ns *corev1.Namespace) (*corev1.ServiceAccount,
 	return sa, err
 }
 
+// adoptSA labels a pipeline sa created by an operator version that did not
+// label it yet, so that it is removed when the namespace is offboarded
+func (r *ReconcileRBAC) adoptSA(sa *corev1.ServiceAccount) error {
+	if _, found := sa.Labels[flag.LabelManagedBy]; found {
+		return nil
+	}
+
+	ctrlLog.WithName("sa").Info("labelling sa", "sa", sa.Name, "ns", sa.Namespace)
+	if sa.Labels == nil {
+		sa.Labels = map[string]string{}
+	}
+	sa.Labels[flag.LabelManagedBy] = flag.ManagedBy
+	return r.client.Update(context.TODO(), sa)
+}
+
 func (r *ReconcileRBAC) ensureRoleBindings(sa *corev1.ServiceAccount) error {
 	log := ctrlLog.WithName("rb").WithValues("ns", sa.Namespace)
 
 	log.Info("finding role-binding edit")
-	rbacClient := r.kc.RbacV1()
-	editRB, rbErr := rbacClient.RoleBindings(sa.Namespace).Get("edit", metav1.GetOptions{})
+	editRB := &rbacv1.RoleBinding{}
+	rbErr := r.client.Get(context.TODO(), types.NamespacedName{Name: "edit", Namespace: sa.Namespace}, editRB)
 	if rbErr != nil && !errors.IsNotFound(rbErr) {
 		log.Error(rbErr, "rbac edit get error")
 		return rbErr
 	}
 
-	log.Info("finding cluster role edit")
-	if _, err := rbacClient.ClusterRoles().Get("edit", metav1.GetOptions{}); err != nil {
-		log.Error(err, "finding edit cluster role failed")
-		return err
-	}
-
 	if rbErr != nil && errors.IsNotFound(rbErr) {
 		return r.createRoleBinding(sa)
 	}
@@ -191,14 +364,13 @@ func (r *ReconcileRBAC) createRoleBinding(sa *corev1.ServiceAccount) error {
 	log := ctrlLog.WithName("rb").WithName("new")
 
 	log.Info("create new rolebinding edit")
-	rbacClient := r.kc.RbacV1()
 	rb := &rbacv1.RoleBinding{
-		ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: sa.Namespace},
+		ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: sa.Namespace, Labels: map[string]string{flag.LabelManagedBy: flag.ManagedBy}},
 		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit"},
 		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: sa.Name, Namespace: sa.Namespace}},
 	}
 
-	_, err := rbacClient.RoleBindings(sa.Namespace).Create(rb)
+	err := r.client.Create(context.TODO(), rb)
 	if err != nil {
 		log.Error(err, "creation of edit rb failed")
 	}
@@ -215,15 +387,14 @@ func (r *ReconcileRBAC) updateRoleBinding(rb *rbacv1.RoleBinding, sa *corev1.Ser
 		return nil
 	}
 
-	log.Info("update existing rolebinding edit")
-	rbacClient := r.kc.RbacV1()
+	log.Info("update existing rolebinding " + rb.Name)
 	rb.Subjects = append(rb.Subjects, subject)
-	_, err := rbacClient.RoleBindings(sa.Namespace).Update(rb)
+	err := r.client.Update(context.TODO(), rb)
 	if err != nil {
-		log.Error(err, "updation of edit rb failed")
+		log.Error(err, "updation of rb failed", "rb", rb.Name)
 		return err
 	}
-	log.Error(err, "successfuilly updated edit rb")
+	log.Info("successfully updated rb", "rb", rb.Name)
 	return nil
 }
 
@@ -236,20 +407,30 @@ func hasSubject(subjects []rbacv1.Subject, x rbacv1.Subject) bool {
 	return false
 }
 
+// ensureSCC applies the security context constraints granted to the pipeline
+// sa. Clusters other than OpenShift have none
+func (r *ReconcileRBAC) ensureSCC() error {
+	err := r.scc.Apply()
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
+
 func (r *ReconcileRBAC) ensureSCClusterRole() error {
 	log := ctrlLog.WithName("rb")
 
-	log.Info("finding cluster role pipeline-anyuid")
+	log.Info("finding cluster role " + flag.PipelinesSCCRole)
 
 	clusterRole := &rbacv1.ClusterRole{
-		ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid},
+		ObjectMeta: metav1.ObjectMeta{Name: flag.PipelinesSCCRole},
 		Rules: []rbacv1.PolicyRule{
 			{
 				APIGroups: []string{
 					"security.openshift.io",
 				},
 				ResourceNames: []string{
-					"anyuid",
+					flag.PipelinesSCC,
 				},
 				Resources: []string{
 					"securitycontextconstraints",
@@ -261,35 +442,60 @@ func (r *ReconcileRBAC) ensureSCClusterRole() error {
 		},
 	}
 
-	rbacClient := r.kc.RbacV1()
-	_, err := rbacClient.ClusterRoles().Get(flag.PipelineAnyuid, metav1.GetOptions{})
+	existing := &rbacv1.ClusterRole{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: flag.PipelinesSCCRole}, existing)
+	switch {
+	case errors.IsNotFound(err):
+		err = r.client.Create(context.TODO(), clusterRole)
+	case err == nil && !equality.Semantic.DeepEqual(existing.Rules, clusterRole.Rules):
+		existing.Rules = clusterRole.Rules
+		err = r.client.Update(context.TODO(), existing)
+	}
+	return err
+}
 
+// deleteLegacyClusterRole deletes the cluster role granting anyuid, which is
+// replaced by the one of the pipelines scc. It is called once a resync pass
+// has migrated the role bindings of every namespace, and leaves the role
+// alone while the components are unmanaged
+func (r *ReconcileRBAC) deleteLegacyClusterRole() {
+	log := ctrlLog.WithName("cluster")
+
+	state, err := r.managementState()
 	if err != nil {
-		if errors.IsNotFound(err) {
-			_, err = rbacClient.ClusterRoles().Create(clusterRole)
-		}
-		return err
+		log.Error(err, "failed to read the management state")
+		return
+	}
+	if state == op.Unmanaged {
+		return
+	}
+
+	legacy := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid}}
+	if err := ignoreNotFound(r.client.Delete(context.TODO(), legacy)); err != nil {
+		log.Error(err, "failed to delete the legacy cluster role "+flag.PipelineAnyuid)
 	}
-	_, err = rbacClient.ClusterRoles().Update(clusterRole)
-	return err
 }
 
-func (r *ReconcileRBAC) ensureSCCRoleBinding(sa *corev1.ServiceAccount) error {
+func (r *ReconcileRBAC) ensureSCCRoleBinding(ns *corev1.Namespace, sa *corev1.ServiceAccount) error {
 
 	log := ctrlLog.WithName("rb").WithValues("ns", sa.Namespace)
 
-	log.Info("finding role-binding pipeline-anyuid")
-	rbacClient := r.kc.RbacV1()
-	pipelineRB, rbErr := rbacClient.RoleBindings(sa.Namespace).Get(flag.PipelineAnyuid, metav1.GetOptions{})
-	if rbErr != nil && !errors.IsNotFound(rbErr) {
-		log.Error(rbErr, "rbac pipeline-anyuid get error")
-		return rbErr
+	// migrate the role binding granting anyuid in earlier versions
+	if err := r.removeSubject(sa.Namespace, flag.PipelineAnyuid); err != nil {
+		return err
 	}
 
-	log.Info("finding cluster role pipeline-anyuid")
-	if _, err := rbacClient.ClusterRoles().Get(flag.PipelineAnyuid, metav1.GetOptions{}); err != nil {
-		log.Error(err, "finding pipeline-anyuid cluster role failed")
-		return err
+	if ns.Annotations[flag.AnnotationSCC] == flag.SCCRestricted {
+		log.Info("namespace falls back to the restricted scc")
+		return r.removeSubject(sa.Namespace, flag.PipelinesSCCRoleBinding)
+	}
+
+	log.Info("finding role-binding " + flag.PipelinesSCCRoleBinding)
+	pipelineRB := &rbacv1.RoleBinding{}
+	rbErr := r.client.Get(context.TODO(), types.NamespacedName{Name: flag.PipelinesSCCRoleBinding, Namespace: sa.Namespace}, pipelineRB)
+	if rbErr != nil && !errors.IsNotFound(rbErr) {
+		log.Error(rbErr, "rbac "+flag.PipelinesSCCRoleBinding+" get error")
+		return rbErr
 	}
 
 	if rbErr != nil && errors.IsNotFound(rbErr) {
@@ -303,17 +509,16 @@ func (r *ReconcileRBAC) ensureSCCRoleBinding(sa *corev1.ServiceAccount) error {
 func (r *ReconcileRBAC) createSCCRoleBinding(sa *corev1.ServiceAccount) error {
 	log := ctrlLog.WithName("rb").WithName("new")
 
-	log.Info("create new rolebinding pipeline-anyuid")
-	rbacClient := r.kc.RbacV1()
+	log.Info("create new rolebinding " + flag.PipelinesSCCRoleBinding)
 	rb := &rbacv1.RoleBinding{
-		ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid, Namespace: sa.Namespace},
-		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: flag.PipelineAnyuid},
+		ObjectMeta: metav1.ObjectMeta{Name: flag.PipelinesSCCRoleBinding, Namespace: sa.Namespace, Labels: map[string]string{flag.LabelManagedBy: flag.ManagedBy}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: flag.PipelinesSCCRole},
 		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: sa.Name, Namespace: sa.Namespace}},
 	}
 
-	_, err := rbacClient.RoleBindings(sa.Namespace).Create(rb)
+	err := r.client.Create(context.TODO(), rb)
 	if err != nil {
-		log.Error(err, "creation of pipeline-anyuid rb failed")
+		log.Error(err, "creation of "+flag.PipelinesSCCRoleBinding+" rb failed")
 	}
 	return err
 }
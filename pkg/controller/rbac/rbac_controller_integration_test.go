@@ -0,0 +1,68 @@
+//go:build integration
+// +build integration
+
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/test/integration"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIntegrationOnboarding(t *testing.T) {
+	env := integration.Start(t)
+	defer env.Stop(t)
+
+	r, err := newReconciler(env.Manager)
+	assertNoError(err, "failed to create the reconciler;", t)
+	assertNoError(add(env.Manager, r), "failed to add the controller;", t)
+	env.StartManager(t)
+
+	ns := env.CreateNamespace(t, "integration-rbac", nil)
+	sa := &corev1.ServiceAccount{}
+	saKey := types.NamespacedName{Namespace: ns.Name, Name: flag.PipelineSA}
+	env.Eventually(t, "the pipeline sa to be created", func() (bool, error) {
+		err := env.Client.Get(context.TODO(), saKey, sa)
+		return err == nil, ignoreNotFound(err)
+	})
+	for _, name := range managedRoleBindings {
+		name := name
+		env.Eventually(t, "role binding "+name+" to bind the pipeline sa", func() (bool, error) {
+			rb := &rbacv1.RoleBinding{}
+			err := env.Client.Get(context.TODO(), types.NamespacedName{Namespace: ns.Name, Name: name}, rb)
+			if err != nil {
+				return false, ignoreNotFound(err)
+			}
+			subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: flag.PipelineSA, Namespace: ns.Name}
+			return hasSubject(rb.Subjects, subject), nil
+		})
+	}
+
+	// the deleted sa is restored through the watch of the managed objects
+	assertNoError(env.Client.Delete(context.TODO(), sa), "failed to delete the pipeline sa;", t)
+	deleted := sa.UID
+	env.Eventually(t, "the pipeline sa to be restored", func() (bool, error) {
+		err := env.Client.Get(context.TODO(), saKey, sa)
+		return err == nil && sa.UID != deleted, ignoreNotFound(err)
+	})
+
+	// a namespace that opts out is offboarded
+	ns = &corev1.Namespace{}
+	assertNoError(env.Client.Get(context.TODO(), types.NamespacedName{Name: "integration-rbac"}, ns), "failed to get the namespace;", t)
+	ns.Annotations = map[string]string{flag.AnnotationOptOut: "true"}
+	assertNoError(env.Client.Update(context.TODO(), ns), "failed to opt the namespace out;", t)
+	env.Eventually(t, "the pipeline sa to be removed", func() (bool, error) {
+		err := env.Client.Get(context.TODO(), saKey, &corev1.ServiceAccount{})
+		return apierrors.IsNotFound(err), ignoreNotFound(err)
+	})
+	env.Eventually(t, "the edit role binding to be removed", func() (bool, error) {
+		err := env.Client.Get(context.TODO(), types.NamespacedName{Namespace: ns.Name, Name: "edit"}, &rbacv1.RoleBinding{})
+		return apierrors.IsNotFound(err), ignoreNotFound(err)
+	})
+}
@@ -0,0 +1,130 @@
+package rbac
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcile(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: flag.DefaultSA, Namespace: ns.Name}
+	anyuid := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid, Namespace: ns.Name},
+		Subjects:   []rbacv1.Subject{subject},
+	}
+	legacy := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid}}
+	cl := newClient(ns, anyuid, legacy, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "edit"}})
+	r := newTestReconciler(t, cl)
+
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+	assertNoError(err, "failed to reconcile namespace;", t)
+
+	sa := &corev1.ServiceAccount{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.DefaultSA, Namespace: ns.Name}, sa), "failed to get sa;", t)
+	if sa.Labels[flag.LabelManagedBy] != flag.ManagedBy {
+		t.Errorf("expected the sa to be labelled as managed, got %v", sa.Labels)
+	}
+	for _, name := range managedRoleBindings {
+		rb := &rbacv1.RoleBinding{}
+		assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns.Name}, rb), "failed to get rolebinding;", t)
+		if !hasSubject(rb.Subjects, subject) {
+			t.Errorf("expected rolebinding %s to bind the sa, got %v", name, rb.Subjects)
+		}
+	}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelinesSCCRole}, &rbacv1.ClusterRole{}), "failed to get scc cluster role;", t)
+
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineAnyuid, Namespace: ns.Name}, &rbacv1.RoleBinding{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the legacy rolebinding to be deleted, got %v", err)
+	}
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineAnyuid}, &rbacv1.ClusterRole{})
+	assertNoError(err, "expected the legacy cluster role to be kept until a resync completes;", t)
+}
+
+func TestLegacyClusterRoleDeletedAfterResync(t *testing.T) {
+	dev := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	prod := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}}
+	legacy := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid}}
+	cl := newClient(dev, prod, legacy, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "edit"}})
+	r := newTestReconciler(t, cl)
+	r.resync = newResync(cl, r.namespaces.ignored, 1000, 0)
+	r.resync.onComplete = r.deleteLegacyClusterRole
+	r.resync.pending = map[string]bool{dev.Name: true, prod.Name: true}
+
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: dev.Name}})
+	assertNoError(err, "failed to reconcile namespace;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineAnyuid}, &rbacv1.ClusterRole{})
+	assertNoError(err, "expected the legacy cluster role to be kept while prod is pending;", t)
+
+	_, err = r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: prod.Name}})
+	assertNoError(err, "failed to reconcile namespace;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelineAnyuid}, &rbacv1.ClusterRole{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the legacy cluster role to be deleted once the resync completed, got %v", err)
+	}
+}
+
+func TestReconcileAdoptsLegacySA(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+	legacy := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultSA, Namespace: ns.Name}}
+	cl := newClient(ns, legacy, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "edit"}})
+	r := newTestReconciler(t, cl)
+
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+	assertNoError(err, "failed to reconcile namespace;", t)
+
+	sa := &corev1.ServiceAccount{}
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.DefaultSA, Namespace: ns.Name}, sa), "failed to get sa;", t)
+	if sa.Labels[flag.LabelManagedBy] != flag.ManagedBy {
+		t.Fatalf("expected the legacy sa to be labelled as managed, got %v", sa.Labels)
+	}
+
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: ns.Name}, ns), "failed to get namespace;", t)
+	assertNoError(r.offboard(ns), "failed to offboard namespace;", t)
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: flag.DefaultSA, Namespace: ns.Name}, &corev1.ServiceAccount{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the adopted sa to be deleted on offboarding, got %v", err)
+	}
+}
+
+func TestEnsureClusterScopedOnce(t *testing.T) {
+	cl := newClient(&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "edit"}})
+	r := newTestReconciler(t, cl)
+	role := &rbacv1.ClusterRole{}
+	key := types.NamespacedName{Name: flag.PipelinesSCCRole}
+
+	assertNoError(r.ensureClusterScoped(), "failed to ensure cluster scoped objects;", t)
+	assertNoError(cl.Get(context.TODO(), key, role), "failed to get scc cluster role;", t)
+	assertNoError(cl.Delete(context.TODO(), role), "failed to delete scc cluster role;", t)
+
+	assertNoError(r.ensureClusterScoped(), "failed to ensure cluster scoped objects;", t)
+	if err := cl.Get(context.TODO(), key, &rbacv1.ClusterRole{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the cluster scoped objects to be ensured once, got %v", err)
+	}
+
+	r.resetClusterScoped()
+	assertNoError(r.ensureClusterScoped(), "failed to ensure cluster scoped objects;", t)
+	assertNoError(cl.Get(context.TODO(), key, &rbacv1.ClusterRole{}), "expected the scc cluster role to be restored;", t)
+}
+
+func newTestReconciler(t *testing.T, cl client.Client) *ReconcileRBAC {
+	t.Helper()
+	scc, err := mf.ManifestFrom(mf.Recursive(filepath.Join("..", "..", "..", "deploy", "resources", "scc")), mf.UseClient(mfc.NewClient(cl)))
+	assertNoError(err, "failed to read scc;", t)
+	e, err := newEligibility(flag.DefaultIgnorePattern, "", "")
+	assertNoError(err, "failed to parse namespace selection;", t)
+	return &ReconcileRBAC{client: cl, scheme: scheme.Scheme, namespaces: e, scc: scc}
+}
@@ -0,0 +1,175 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var resyncDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "tekton_operator_rbac_resync_duration_seconds",
+	Help:    "Time taken by the rbac controller to reconcile every namespace once",
+	Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+})
+
+func init() {
+	metrics.Registry.MustRegister(resyncDuration)
+}
+
+// resync reconciles every eligible namespace in passes: when the controller
+// starts, every period and when the namespace policy changes. The namespaces
+// of a pass are enqueued at a limited rate, so that a restart of the operator
+// does not flood the apiserver
+type resync struct {
+	client  client.Client
+	ignored func(name string) bool
+	limiter *rate.Limiter
+	period  time.Duration
+
+	// events receives the namespaces of a pass, it is the source of a watch
+	events chan event.GenericEvent
+
+	// triggered starts a pass out of the period
+	triggered chan struct{}
+
+	// onPass is called when a pass starts, before any namespace is enqueued
+	onPass func()
+
+	// onComplete is called once every namespace of a pass has been reconciled
+	onComplete func()
+
+	mu      sync.Mutex
+	started time.Time
+	pending map[string]bool
+}
+
+func newResync(c client.Client, ignored func(string) bool, qps float64, period time.Duration) *resync {
+	burst := int(qps)
+	if burst < 1 {
+		burst = 1
+	}
+	return &resync{
+		client:    c,
+		ignored:   ignored,
+		limiter:   rate.NewLimiter(rate.Limit(qps), burst),
+		period:    period,
+		events:    make(chan event.GenericEvent),
+		triggered: make(chan struct{}, 1),
+	}
+}
+
+// trigger starts a pass as soon as the current one has been enqueued
+func (s *resync) trigger() {
+	select {
+	case s.triggered <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs a pass right away, then every period or when triggered, until
+// stop is closed. It implements manager.Runnable
+func (s *resync) Start(stop <-chan struct{}) error {
+	var tick <-chan time.Time
+	if s.period > 0 {
+		ticker := time.NewTicker(s.period)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		if err := s.pass(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			ctrlLog.Error(err, "resync of namespaces failed")
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-tick:
+		case <-s.triggered:
+		}
+	}
+}
+
+// pass enqueues every namespace that is not ignored at the rate of the limiter
+func (s *resync) pass(ctx context.Context) error {
+	namespaces := &corev1.NamespaceList{}
+	if err := s.client.List(ctx, namespaces); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		if !s.ignored(ns.Name) {
+			pending[ns.Name] = true
+		}
+	}
+
+	s.mu.Lock()
+	if len(s.pending) > 0 {
+		ctrlLog.Info("starting resync before the previous one completed", "pending", len(s.pending))
+	}
+	s.started = time.Now()
+	s.pending = pending
+	s.mu.Unlock()
+	ctrlLog.Info("resync of namespaces", "namespaces", len(pending))
+
+	if s.onPass != nil {
+		s.onPass()
+	}
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if !pending[ns.Name] {
+			continue
+		}
+		if err := s.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		select {
+		case s.events <- event.GenericEvent{Meta: ns, Object: ns}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// reconciled records that the namespace name has been reconciled, and the
+// duration of the pass when it was the last namespace of the pass
+func (s *resync) reconciled(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.pending[name] {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.pending, name)
+	complete := len(s.pending) == 0
+	if complete {
+		d := time.Since(s.started)
+		resyncDuration.Observe(d.Seconds())
+		ctrlLog.Info("resync of namespaces completed", "duration", d.String())
+	}
+	s.mu.Unlock()
+
+	if complete && s.onComplete != nil {
+		s.onComplete()
+	}
+}
@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestResyncPass(t *testing.T) {
+	e, err := newEligibility(flag.DefaultIgnorePattern, "", "")
+	assertNoError(err, "failed to parse namespace selection;", t)
+	cl := newClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "openshift-config"}},
+	)
+	s := newResync(cl, e.ignored, 1000, 0)
+	passes := 0
+	s.onPass = func() { passes++ }
+
+	done := make(chan error)
+	go func() { done <- s.pass(context.TODO()) }()
+
+	var enqueued []string
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-s.events:
+			enqueued = append(enqueued, ev.Meta.GetName())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the namespaces of the pass")
+		}
+	}
+	assertNoError(<-done, "failed to resync;", t)
+	sort.Strings(enqueued)
+	if len(enqueued) != 2 || enqueued[0] != "dev" || enqueued[1] != "prod" {
+		t.Errorf("expected dev and prod to be enqueued, got %v", enqueued)
+	}
+	if passes != 1 {
+		t.Errorf("expected onPass to be called once, got %d", passes)
+	}
+
+	s.reconciled("dev")
+	s.reconciled("openshift-config")
+	if len(s.pending) != 1 {
+		t.Errorf("expected prod to be pending, got %v", s.pending)
+	}
+	s.reconciled("prod")
+	if len(s.pending) != 0 {
+		t.Errorf("expected the pass to be completed, got %v", s.pending)
+	}
+}
+
+func TestNamespaceChangedSkipsExistingNamespaces(t *testing.T) {
+	since := time.Now()
+	old := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: metav1.NewTime(since.Add(-time.Hour))}}
+	created := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new", CreationTimestamp: metav1.NewTime(since.Add(time.Second))}}
+
+	p := namespaceChanged(since)
+	if p.Create(eventFor(old)) {
+		t.Error("expected namespaces existing at startup to be left to the resync")
+	}
+	if !p.Create(eventFor(created)) {
+		t.Error("expected new namespaces to be reconciled right away")
+	}
+}
+
+func eventFor(ns *corev1.Namespace) event.CreateEvent {
+	return event.CreateEvent{Meta: ns, Object: ns}
+}
@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestEnsureSCC(t *testing.T) {
+	cl := newClient()
+	scc, err := mf.ManifestFrom(mf.Recursive(filepath.Join("..", "..", "..", "deploy", "resources", "scc")), mf.UseClient(mfc.NewClient(cl)))
+	assertNoError(err, "failed to read scc;", t)
+	r := &ReconcileRBAC{client: cl, scheme: scheme.Scheme, scc: scc}
+
+	assertNoError(r.ensureSCC(), "failed to apply scc;", t)
+
+	got := &unstructured.Unstructured{}
+	got.SetAPIVersion("security.openshift.io/v1")
+	got.SetKind("SecurityContextConstraints")
+	assertNoError(cl.Get(context.TODO(), types.NamespacedName{Name: flag.PipelinesSCC}, got), "failed to get scc;", t)
+	if privileged, _, _ := unstructured.NestedBool(got.Object, "allowPrivilegedContainer"); privileged {
+		t.Error("expected the scc to forbid privileged containers")
+	}
+	if capabilities, _, _ := unstructured.NestedStringSlice(got.Object, "allowedCapabilities"); len(capabilities) != 1 || capabilities[0] != "SETFCAP" {
+		t.Errorf("expected the scc to only allow SETFCAP, got %v", capabilities)
+	}
+}
+
+func TestEnsureSCCRoleBindingRestricted(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "dev",
+		Annotations: map[string]string{flag.AnnotationSCC: flag.SCCRestricted},
+	}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultSA, Namespace: ns.Name}}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: flag.DefaultSA, Namespace: ns.Name}}
+	anyuid := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: flag.PipelineAnyuid, Namespace: ns.Name}, Subjects: subjects}
+	pipelines := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: flag.PipelinesSCCRoleBinding, Namespace: ns.Name}, Subjects: subjects}
+	cl := newClient(ns, sa, anyuid, pipelines)
+	r := &ReconcileRBAC{client: cl, scheme: scheme.Scheme}
+
+	assertNoError(r.ensureSCCRoleBinding(ns, sa), "failed to ensure scc rolebinding;", t)
+
+	for _, name := range []string{flag.PipelineAnyuid, flag.PipelinesSCCRoleBinding} {
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns.Name}, &rbacv1.RoleBinding{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected rolebinding %s to be deleted, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package rbac
+
+import (
+	"github.com/tektoncd/operator/pkg/flag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// managedRoleBindings are the role bindings the pipeline sa is a subject of
+var managedRoleBindings = []string{"edit", flag.PipelinesSCCRoleBinding}
+
+// enqueueNamespace maps an event of a namespaced object to a request for its
+// namespace
+var enqueueNamespace = &handler.EnqueueRequestsFromMapFunc{
+	ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: o.Meta.GetNamespace()}}}
+	}),
+}
+
+// isManaged passes the updates and deletes of the objects with the given
+// names; they are created by the reconcile of their namespace
+func isManaged(names ...string) predicate.Funcs {
+	matches := func(m metav1.Object) bool {
+		for _, name := range names {
+			if m.GetName() == name {
+				return true
+			}
+		}
+		return false
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return matches(e.MetaNew) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return matches(e.Meta) },
+		GenericFunc: func(e event.GenericEvent) bool { return matches(e.Meta) },
+	}
+}
+
+// resyncOnEvent starts a resync of every namespace on any event, e.g. when the
+// namespace policy changes
+func resyncOnEvent(s *resync) handler.Funcs {
+	return handler.Funcs{
+		CreateFunc:  func(event.CreateEvent, workqueue.RateLimitingInterface) { s.trigger() },
+		UpdateFunc:  func(event.UpdateEvent, workqueue.RateLimitingInterface) { s.trigger() },
+		DeleteFunc:  func(event.DeleteEvent, workqueue.RateLimitingInterface) { s.trigger() },
+		GenericFunc: func(event.GenericEvent, workqueue.RateLimitingInterface) { s.trigger() },
+	}
+}
@@ -0,0 +1,40 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestIsManaged(t *testing.T) {
+	managed := isManaged(managedRoleBindings...)
+	edit := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: "dev"}}
+	other := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "view", Namespace: "dev"}}
+
+	if !managed.Delete(event.DeleteEvent{Meta: edit, Object: edit}) {
+		t.Error("expected the deletion of a managed rolebinding to pass")
+	}
+	if !managed.Update(event.UpdateEvent{MetaOld: edit, ObjectOld: edit, MetaNew: edit, ObjectNew: edit}) {
+		t.Error("expected the update of a managed rolebinding to pass")
+	}
+	if managed.Delete(event.DeleteEvent{Meta: other, Object: other}) {
+		t.Error("expected the deletion of another rolebinding to be filtered")
+	}
+	if managed.Create(event.CreateEvent{Meta: edit, Object: edit}) {
+		t.Error("expected the creation of a managed rolebinding to be filtered")
+	}
+}
+
+func TestEnqueueNamespace(t *testing.T) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: flag.DefaultSA, Namespace: "dev"}}
+
+	requests := enqueueNamespace.ToRequests.Map(handler.MapObject{Meta: sa, Object: sa})
+	if len(requests) != 1 || requests[0].Name != "dev" || requests[0].Namespace != "" {
+		t.Errorf("expected a request for namespace dev, got %v", requests)
+	}
+}
@@ -2,8 +2,10 @@ package flag
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 )
@@ -40,9 +42,19 @@ const (
 	TriggerWebhookName          = "tekton-triggers-webhook"
 	TriggerWebhookConfiguration = "webhook.triggers.tekton.dev"
 
+	// Name of the objects used to dry-run the admission webhooks
+	WebhookProbeName = "operator-webhook-probe"
+
 	AnnotationPreserveNS          = "operator.tekton.dev/preserve-namespace"
 	AnnotationPreserveRBSubjectNS = "operator.tekton.dev/preserve-rb-subject-namespace"
+	AnnotationRetry               = "operator.tekton.dev/retry"
+	AnnotationReconcileRequest    = "operator.tekton.dev/reconcile-request"
+	AnnotationForceUpgrade        = "operator.tekton.dev/force-upgrade"
 	LabelProviderType             = "operator.tekton.dev/provider-type"
+	LabelInstance                 = "operator.tekton.dev/instance"
+	AnnotationTrustedCAHash       = "operator.tekton.dev/trusted-ca-hash"
+	AnnotationOnboardingStatus    = "operator.tekton.dev/onboarding-status"
+	AnnotationOptOut              = "operator.tekton.dev/opt-out"
 	ProviderTypeCommunity         = "community"
 	ProviderTypeRedHat            = "redhat"
 	ProviderTypeCertified         = "certified"
@@ -52,10 +64,85 @@ const (
 	LabelPipelineRuntime                = "pipeline.openshift.io/runtime"
 	LabelPipelineStrategy               = "pipeline.openshift.io/strategy"
 
+	// LabelSnippetTask names the ClusterTask a ConsoleYAMLSample snippet was
+	// generated from
+	LabelSnippetTask = "operator.tekton.dev/snippet-task"
+
+	// PipelineAnyuid is the cluster role and the role bindings granting the
+	// anyuid scc to the pipeline sa in earlier versions
 	PipelineAnyuid = "pipeline-anyuid"
 
+	// PipelinesSCC is the scc granted to the pipeline sa through
+	// PipelinesSCCRole and a PipelinesSCCRoleBinding in each namespace
+	PipelinesSCC            = "pipelines-scc"
+	PipelinesSCCRole        = "pipelines-scc-clusterrole"
+	PipelinesSCCRoleBinding = "pipelines-scc-rolebinding"
+
+	// AnnotationSCC set to SCCRestricted on a namespace leaves the pipeline sa
+	// with the restricted scc every user is granted
+	AnnotationSCC = "operator.tekton.dev/scc"
+	SCCRestricted = "restricted"
+
+	// DefaultNamespacePolicy is the ConfigMap in the operator namespace
+	// declaring what is set up in each namespace next to the pipeline sa
+	DefaultNamespacePolicy = "pipelines-namespace-policy"
+	PipelineLimitRange     = "pipeline-limits"
+
+	// LabelManagedBy marks the objects the operator created and may remove
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	ManagedBy      = "openshift-pipelines-operator"
+
+	// LabelPartOf is set on the objects installed for a config that do not
+	// carry it yet, the upstream releases set it on their own objects
+	LabelPartOf = "app.kubernetes.io/part-of"
+	PartOf      = "openshift-pipelines"
+
+	// LabelComponent is set on every object installed for a config, with the
+	// component of the install it belongs to. It is separate from the
+	// app.kubernetes.io/component label of the upstream releases, which
+	// their webhooks select objects by
+	LabelComponent = "operator.tekton.dev/component"
+
+	// LabelInstallGeneration identifies the operator version and releases an
+	// object was last applied for
+	LabelInstallGeneration = "operator.tekton.dev/install-generation"
+
 	uuidPath     = "deploy/uuid"
 	TemplatePath = "deploy/resources/templates"
+
+	// DefaultPhaseDeadline is the time an install phase may keep retrying
+	// before it is marked as failed
+	DefaultPhaseDeadline = 30 * time.Minute
+
+	// DefaultRBACResyncPeriod is the time after which the rbac of a
+	// namespace is reconciled again
+	DefaultRBACResyncPeriod = 10 * time.Minute
+
+	// DefaultWebhookPort is the port the admission webhooks are served at
+	DefaultWebhookPort = 9443
+
+	// DefaultRBACResyncQPS is the number of namespaces per second enqueued
+	// by a resync of the rbac controller
+	DefaultRBACResyncQPS = 10.0
+
+	// ClusterProxyName is the name of the cluster wide proxy configuration
+	ClusterProxyName = "cluster"
+
+	// TrustedCAConfigMap holds the CA bundle trusted by the controllers in the
+	// target namespace; on OpenShift it is filled by the cluster network operator
+	TrustedCAConfigMap   = "config-trusted-cabundle"
+	TrustedCAKey         = "ca-bundle.crt"
+	LabelInjectTrustedCA = "config.openshift.io/inject-trusted-cabundle"
+	TrustedCAMountPath   = "/etc/config-trusted-cabundle"
+	DefaultSSLCertDirs   = "/etc/ssl/certs:/etc/pki/tls/certs"
+
+	// DefaultHAReplicas is the number of replicas of each controller and
+	// webhook when high availability is enabled
+	DefaultHAReplicas int32 = 2
+
+	// DefaultTknDownloadURL is the mirror the tkn archives linked from the
+	// console are downloaded from
+	DefaultTknDownloadURL = "https://mirror.openshift.com/pub/openshift-v4/clients/pipeline"
 )
 
 var (
@@ -64,6 +151,9 @@ var (
 	TektonVersion          = "devel"
 	PipelineSA             string
 	IgnorePattern          string
+	NamespacePolicy        string
+	IncludeNsSelector      string
+	ExcludeNsSelector      string
 	ResourceWatched        string
 	ResourceDir            string
 	TargetNamespace        string
@@ -71,6 +161,12 @@ var (
 	SkipNonRedHatResources bool
 	Recursive              bool
 	OperatorUUID           string
+	PhaseDeadline          time.Duration
+	RBACResyncPeriod       time.Duration
+	RBACResyncQPS          float64
+	WebhookPort            int
+	WebhookCertDir         string
+	TknDownloadURL         string
 	CommunityResourceURLs  = []string{
 		"https://raw.githubusercontent.com/tektoncd/catalog/master/task/jib-maven/0.1/jib-maven.yaml",
 		"https://raw.githubusercontent.com/tektoncd/catalog/master/task/maven/0.1/maven.yaml",
@@ -111,10 +207,19 @@ func init() {
 	flagSet.StringVar(
 		&IgnorePattern, "ignore-ns-matching", DefaultIgnorePattern,
 		"Namespaces to ignore where SA will be auto-created; default: "+DefaultIgnorePattern)
+	flagSet.StringVar(
+		&IncludeNsSelector, "include-ns-selector", "",
+		"Label selector of the namespaces where SA will be auto-created; default: all namespaces")
+	flagSet.StringVar(
+		&ExcludeNsSelector, "exclude-ns-selector", "",
+		"Label selector of the namespaces to ignore where SA will be auto-created; default: none")
+	flagSet.StringVar(
+		&NamespacePolicy, "namespace-policy", DefaultNamespacePolicy,
+		"ConfigMap in the operator namespace declaring the secrets, limits and claims set up in each namespace; default: "+DefaultNamespacePolicy)
 
 	flagSet.StringVar(
 		&ResourceWatched, "watch-resource", ClusterCRName,
-		"cluster-wide resource that operator honours, default: "+ClusterCRName)
+		"config resource that is auto created and installs the cluster-wide addons, default: "+ClusterCRName)
 
 	flagSet.StringVar(
 		&TargetNamespace, "target-namespace", DefaultTargetNs,
@@ -125,6 +230,10 @@ func init() {
 		&ResourceDir, "resource-dir", defaultResDir,
 		"Path to resource manifests, default: "+defaultResDir)
 
+	flagSet.StringVar(
+		&TknDownloadURL, "tkn-download-url", DefaultTknDownloadURL,
+		"Base URL of the tkn archives linked from the console, laid out as <url>/<version>/tkn-<os>-<arch>-<version>.<ext>, default: "+DefaultTknDownloadURL)
+
 	flagSet.BoolVar(
 		&NoAutoInstall, "no-auto-install", false,
 		"Do not automatically install tekton pipelines, default: false")
@@ -136,6 +245,27 @@ func init() {
 	flagSet.BoolVar(
 		&Recursive, "recursive", true,
 		"If enabled apply manifest file in resource directory recursively")
+
+	flagSet.IntVar(
+		&WebhookPort, "webhook-port", DefaultWebhookPort,
+		"Port the admission webhooks are served at, default: 9443")
+
+	defaultCertDir := filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+	flagSet.StringVar(
+		&WebhookCertDir, "webhook-cert-dir", defaultCertDir,
+		"Directory holding the tls.crt and tls.key serving the admission webhooks, they are disabled without them, default: "+defaultCertDir)
+
+	flagSet.DurationVar(
+		&PhaseDeadline, "phase-deadline", DefaultPhaseDeadline,
+		"Time an install phase may keep retrying before it is marked as failed, default: "+DefaultPhaseDeadline.String())
+
+	flagSet.DurationVar(
+		&RBACResyncPeriod, "rbac-resync-period", DefaultRBACResyncPeriod,
+		"Time after which the SA and role bindings of every namespace are reconciled again, 0 disables it, default: "+DefaultRBACResyncPeriod.String())
+
+	flagSet.Float64Var(
+		&RBACResyncQPS, "rbac-resync-qps", DefaultRBACResyncQPS,
+		"Namespaces per second reconciled by a resync, including the one at startup, default: 10")
 }
 func FlagSet() *pflag.FlagSet {
 	return flagSet
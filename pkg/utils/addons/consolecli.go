@@ -0,0 +1,87 @@
+package addons
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"golang.org/x/mod/semver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// tknVersions maps the minor releases of Tekton Pipelines to the tkn release
+// shipped with them
+var tknVersions = map[string]string{
+	"v0.14": "0.11.0",
+	"v0.15": "0.12.0",
+	"v0.16": "0.13.1",
+	"v0.17": "0.14.0",
+	"v0.18": "0.15.0",
+}
+
+// tknPlatforms are the archives tkn is released as, in the order they are
+// listed by the console
+var tknPlatforms = []struct {
+	text string
+	os   string
+	arch string
+	ext  string
+}{
+	{text: "Linux for x86_64", os: "linux", arch: "amd64", ext: "tar.gz"},
+	{text: "Linux for ARM 64", os: "linux", arch: "arm64", ext: "tar.gz"},
+	{text: "Linux for IBM Power", os: "linux", arch: "ppc64le", ext: "tar.gz"},
+	{text: "Linux for IBM Z", os: "linux", arch: "s390x", ext: "tar.gz"},
+	{text: "Mac", os: "macos", arch: "amd64", ext: "tar.gz"},
+	{text: "Windows", os: "windows", arch: "amd64", ext: "zip"},
+}
+
+// TknVersion returns the tkn release for the pipeline version, falling back
+// to the closest older pipeline release it knows of, or to the latest tkn
+// release when the version is not a release
+func TknVersion(pipelineVersion string) string {
+	var minors []string
+	for minor := range tknVersions {
+		minors = append(minors, minor)
+	}
+	sort.Slice(minors, func(i, j int) bool {
+		return semver.Compare(minors[i], minors[j]) > 0
+	})
+
+	if !semver.IsValid(pipelineVersion) {
+		return tknVersions[minors[0]]
+	}
+	wanted := semver.MajorMinor(pipelineVersion)
+	for _, minor := range minors {
+		if semver.Compare(minor, wanted) <= 0 {
+			return tknVersions[minor]
+		}
+	}
+	return tknVersions[minors[len(minors)-1]]
+}
+
+// TknDownloadLinks sets the links of the ConsoleCLIDownload resources to the
+// archives of the tkn release for the pipeline version, laid out under
+// baseURL as on mirror.openshift.com: <baseURL>/<version>/tkn-<os>-<arch>-<version>.<ext>
+func TknDownloadLinks(pipelineVersion, baseURL string) mf.Transformer {
+	version := TknVersion(pipelineVersion)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	var links []interface{}
+	for _, p := range tknPlatforms {
+		links = append(links, map[string]interface{}{
+			"href": fmt.Sprintf("%s/%s/tkn-%s-%s-%s.%s", baseURL, version, p.os, p.arch, version, p.ext),
+			"text": "Download tkn for " + p.text,
+		})
+	}
+
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConsoleCLIDownload" {
+			return nil
+		}
+		if err := unstructured.SetNestedSlice(u.Object, links, "spec", "links"); err != nil {
+			return fmt.Errorf("error updating links for %s:%s, %s", u.GetKind(), u.GetName(), err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package addons
+
+import (
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTknVersion(t *testing.T) {
+	for pipelineVersion, want := range map[string]string{
+		"v0.18.0": "0.15.0",
+		"v0.17.3": "0.14.0",
+		"v0.15.2": "0.12.0",
+		"v0.14.3": "0.11.0",
+		"v0.12.1": "0.11.0",
+		"v0.21.0": "0.15.0",
+		"devel":   "0.15.0",
+	} {
+		if got := TknVersion(pipelineVersion); got != want {
+			t.Errorf("expected tkn %s for pipeline %s, got %s", want, pipelineVersion, got)
+		}
+	}
+}
+
+func TestTknDownloadLinks(t *testing.T) {
+	download := unstructured.Unstructured{}
+	download.SetAPIVersion("console.openshift.io/v1")
+	download.SetKind("ConsoleCLIDownload")
+	download.SetName("tkn")
+	m, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{download}))
+	assertNoEror(t, err)
+
+	m, err = m.Transform(TknDownloadLinks("v0.17.3", "https://artifacts.example.com/tkn/"))
+	assertNoEror(t, err)
+
+	links, _, err := unstructured.NestedSlice(m.Resources()[0].Object, "spec", "links")
+	assertNoEror(t, err)
+	if len(links) != len(tknPlatforms) {
+		t.Fatalf("expected a link for each of the %d platforms, got %v", len(tknPlatforms), links)
+	}
+	for i, want := range []string{
+		"https://artifacts.example.com/tkn/0.14.0/tkn-linux-amd64-0.14.0.tar.gz",
+		"https://artifacts.example.com/tkn/0.14.0/tkn-linux-arm64-0.14.0.tar.gz",
+		"https://artifacts.example.com/tkn/0.14.0/tkn-linux-ppc64le-0.14.0.tar.gz",
+		"https://artifacts.example.com/tkn/0.14.0/tkn-linux-s390x-0.14.0.tar.gz",
+		"https://artifacts.example.com/tkn/0.14.0/tkn-macos-amd64-0.14.0.tar.gz",
+		"https://artifacts.example.com/tkn/0.14.0/tkn-windows-amd64-0.14.0.zip",
+	} {
+		if got := links[i].(map[string]interface{})["href"]; got != want {
+			t.Errorf("expected link %d to %s, got %v", i, want, got)
+		}
+	}
+}
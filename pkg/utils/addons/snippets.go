@@ -0,0 +1,132 @@
+package addons
+
+import (
+	"fmt"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/tektoncd/operator/pkg/flag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const annotationDisplayName = "tekton.dev/displayName"
+
+// TaskSnippets returns a ConsoleYAMLSample snippet for each of the Tasks and
+// ClusterTasks of m, which are all installed as ClusterTasks. The snippet is
+// the pipeline task running it, with its params, resources and workspaces
+func TaskSnippets(m mf.Manifest) (mf.Manifest, error) {
+	var snippets []unstructured.Unstructured
+	for _, task := range m.Filter(mf.Any(mf.ByKind("ClusterTask"), mf.ByKind("Task"))).Resources() {
+		snippet, err := taskSnippet(task)
+		if err != nil {
+			return mf.Manifest{}, fmt.Errorf("failed to generate the snippet of task %s: %w", task.GetName(), err)
+		}
+		snippets = append(snippets, snippet)
+	}
+	return mf.ManifestFrom(mf.Slice(snippets), mf.UseClient(m.Client))
+}
+
+func taskSnippet(task unstructured.Unstructured) (unstructured.Unstructured, error) {
+	name := task.GetName()
+	pipelineTask := map[string]interface{}{
+		"name":    name,
+		"taskRef": map[string]interface{}{"name": name, "kind": "ClusterTask"},
+	}
+
+	params, _, err := unstructured.NestedSlice(task.Object, "spec", "params")
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	if len(params) > 0 {
+		pipelineTask["params"] = snippetParams(params)
+	}
+
+	workspaces, _, err := unstructured.NestedSlice(task.Object, "spec", "workspaces")
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	if len(workspaces) > 0 {
+		pipelineTask["workspaces"] = bindByName(workspaces, "workspace")
+	}
+
+	resources := map[string]interface{}{}
+	for _, direction := range []string{"inputs", "outputs"} {
+		declared, _, err := unstructured.NestedSlice(task.Object, "spec", "resources", direction)
+		if err != nil {
+			return unstructured.Unstructured{}, err
+		}
+		if len(declared) > 0 {
+			resources[direction] = bindByName(declared, "resource")
+		}
+	}
+	if len(resources) > 0 {
+		pipelineTask["resources"] = resources
+	}
+
+	body, err := yaml.Marshal([]interface{}{pipelineTask})
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	title := task.GetAnnotations()[annotationDisplayName]
+	if title == "" {
+		title = name
+	}
+	description, _, _ := unstructured.NestedString(task.Object, "spec", "description")
+	if description = strings.TrimSpace(description); description == "" {
+		description = "Runs the " + name + " ClusterTask"
+	}
+
+	snippet := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targetResource": map[string]interface{}{"apiVersion": "tekton.dev/v1beta1", "kind": "Pipeline"},
+			"title":          title,
+			"description":    description,
+			"snippet":        true,
+			"yaml":           string(body),
+		},
+	}}
+	snippet.SetAPIVersion("console.openshift.io/v1")
+	snippet.SetKind("ConsoleYAMLSample")
+	snippet.SetName(name + "-snippet")
+	snippet.SetLabels(map[string]string{flag.LabelSnippetTask: name})
+	return snippet, nil
+}
+
+// snippetParams sets each param to its default, or to the pipeline param of
+// the same name when it has none
+func snippetParams(params []interface{}) []interface{} {
+	var values []interface{}
+	for _, p := range params {
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := param["name"].(string)
+		value, found := param["default"]
+		if !found {
+			value = "$(params." + name + ")"
+			if param["type"] == "array" {
+				value = []interface{}{"$(params." + name + "[*])"}
+			}
+		}
+		values = append(values, map[string]interface{}{"name": name, "value": value})
+	}
+	return values
+}
+
+// bindByName binds each declaration of the task to the pipeline field of the
+// same name, e.g. a workspace to the pipeline workspace
+func bindByName(declared []interface{}, field string) []interface{} {
+	var bindings []interface{}
+	for _, d := range declared {
+		declaration, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := declaration["name"].(string)
+		bindings = append(bindings, map[string]interface{}{"name": name, field: name})
+	}
+	return bindings
+}
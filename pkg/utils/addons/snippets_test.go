@@ -0,0 +1,32 @@
+package addons
+
+import (
+	"path"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/tektoncd/operator/pkg/flag"
+	"gotest.tools/golden"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTaskSnippets(t *testing.T) {
+	tasks, err := mf.NewManifest(path.Join("testdata", "tasks.yaml"))
+	assertNoEror(t, err)
+
+	snippets, err := TaskSnippets(tasks)
+	assertNoEror(t, err)
+	if got := len(snippets.Resources()); got != 2 {
+		t.Fatalf("expected a snippet for each task, got %d", got)
+	}
+
+	for _, snippet := range snippets.Resources() {
+		task := snippet.GetLabels()[flag.LabelSnippetTask]
+		if snippet.GetName() != task+"-snippet" {
+			t.Errorf("expected the snippet of %s to be named %s-snippet, got %s", task, task, snippet.GetName())
+		}
+		body, _, err := unstructured.NestedString(snippet.Object, "spec", "yaml")
+		assertNoEror(t, err)
+		golden.Assert(t, body, snippet.GetName()+".golden")
+	}
+}
@@ -0,0 +1,35 @@
+package informer
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ConfigMaps returns a source of the events of the ConfigMaps named name, in
+// namespace or in every namespace when namespace is empty. A source.Kind would
+// have the cache of mgr hold every ConfigMap of the cluster; this informer
+// only holds the selected ones and is started with mgr
+func ConfigMaps(mgr manager.Manager, namespace, name string) (source.Source, error) {
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		informer.Run(stop)
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &source.Informer{Informer: informer}, nil
+}
@@ -0,0 +1,205 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression of five fields: minute, hour, day of
+// month, month and day of week. Times are matched in UTC
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// restrictedDays is true when neither the day of month nor the day of
+	// week starts with "*", a day then matches either of them as in cron
+	restrictedDays bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is Sunday too
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Parse parses expr, e.g. "0 22 * * 1-5" for 22:00 on weekdays. Each field
+// is "*" or a list of values and ranges, optionally with a step like "*/15"
+// or "8-18/2". Months and days of week may be given by their first three
+// letters
+func Parse(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute, hour, day of month, month, day of week), got %d", len(fields))
+	}
+
+	s := &Schedule{}
+	var err error
+	for i, f := range []struct {
+		field
+		bits *uint64
+	}{
+		{minuteField, &s.minute},
+		{hourField, &s.hour},
+		{domField, &s.dom},
+		{monthField, &s.month},
+		{dowField, &s.dow},
+	} {
+		if *f.bits, err = f.parse(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	// Sunday is 0 for time.Weekday
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.restrictedDays = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+
+	if s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("%q never matches", expr)
+	}
+	return s, nil
+}
+
+// parse returns the bits of the values of the field matched by expr
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		valueRange, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q of %s", part[i+1:], f.name)
+			}
+			valueRange, step = part[:i], n
+		}
+
+		first, last := f.min, f.max
+		switch i := strings.Index(valueRange, "-"); {
+		case valueRange == "*":
+		case i >= 0:
+			var err error
+			if first, err = f.value(valueRange[:i]); err != nil {
+				return 0, err
+			}
+			if last, err = f.value(valueRange[i+1:]); err != nil {
+				return 0, err
+			}
+			if first > last {
+				return 0, fmt.Errorf("invalid range %q of %s", valueRange, f.name)
+			}
+		default:
+			var err error
+			if first, err = f.value(valueRange); err != nil {
+				return 0, err
+			}
+			// a single value with a step runs up to the maximum, as in cron
+			if step == 1 {
+				last = first
+			}
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Matches reports whether the minute of t is one of the schedule
+func (s *Schedule) Matches(t time.Time) bool {
+	t = t.UTC()
+	return has(s.month, int(t.Month())) && s.matchesDay(t) &&
+		has(s.hour, t.Hour()) && has(s.minute, t.Minute())
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if s.restrictedDays {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// Next returns the first minute of the schedule after t, or the zero time
+// when there is none within five years, e.g. for the 30th of February
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(s.hour, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+// Window is a recurring period that opens at the times of a schedule and
+// stays open for a duration
+type Window struct {
+	Start    *Schedule
+	Duration time.Duration
+}
+
+// NewWindow parses the schedule of the window
+func NewWindow(schedule string, duration time.Duration) (*Window, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive, got %s", duration)
+	}
+	start, err := Parse(schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &Window{Start: start, Duration: duration}, nil
+}
+
+// Open reports whether the window is open at t
+func (w *Window) Open(t time.Time) bool {
+	start := w.Start.Next(t.Add(-w.Duration))
+	return !start.IsZero() && !start.After(t)
+}
+
+// NextOpen returns t when the window is open at t, or the next time it opens
+func (w *Window) NextOpen(t time.Time) time.Time {
+	if w.Open(t) {
+		return t
+	}
+	return w.Start.Next(t)
+}
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		err  string
+	}{
+		{expr: "0 22 * * 1-5"},
+		{expr: "*/15 8-18/2 1,15 jan-jun sun"},
+		{expr: "30 2 * * 7"},
+		{expr: "0 22 * *", err: "expected 5 fields"},
+		{expr: "60 * * * *", err: "invalid minute"},
+		{expr: "0 18-8 * * *", err: "invalid range"},
+		{expr: "*/0 * * * *", err: "invalid step"},
+		{expr: "0 0 30 feb *", err: "never matches"},
+	} {
+		_, err := Parse(tc.expr)
+		if tc.err == "" && err != nil {
+			t.Errorf("%q: unexpected error %v", tc.expr, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%q: expected an error with %q, got %v", tc.expr, tc.err, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// a Friday
+	from := time.Date(2020, 11, 6, 22, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 22 * * 1-5", time.Date(2020, 11, 9, 22, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2020, 11, 6, 22, 40, 0, 0, time.UTC)},
+		{"30 2 * * sun", time.Date(2020, 11, 8, 2, 30, 0, 0, time.UTC)},
+		{"30 2 * * 7", time.Date(2020, 11, 8, 2, 30, 0, 0, time.UTC)},
+		// either the day of month or the day of week
+		{"0 0 10 * sat", time.Date(2020, 11, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	} {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", tc.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q: expected %s, got %s", tc.expr, tc.want, got)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	w, err := NewWindow("0 22 * * 1-5", 2*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2020, 11, 6, hour, minute, 0, 0, time.UTC)
+	}
+
+	for _, tc := range []struct {
+		at   time.Time
+		open bool
+	}{
+		{friday(21, 59), false},
+		{friday(22, 0), true},
+		{friday(23, 59), true},
+		{friday(24, 0), false},
+	} {
+		if got := w.Open(tc.at); got != tc.open {
+			t.Errorf("%s: expected open %v, got %v", tc.at, tc.open, got)
+		}
+	}
+	if got, want := w.NextOpen(friday(23, 0)), friday(23, 0); !got.Equal(want) {
+		t.Errorf("expected the open window to be open now, got %s", got)
+	}
+	if got, want := w.NextOpen(friday(24, 0)), time.Date(2020, 11, 9, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected the window to open on Monday %s, got %s", want, got)
+	}
+
+	if _, err := NewWindow("0 22 * * 1-5", 0); err == nil {
+		t.Errorf("expected an error for a window without duration")
+	}
+}
@@ -0,0 +1,108 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// Overlays applies the patches of overlays, in order, to the objects they
+// target
+func Overlays(overlays []op.Overlay) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		for i, o := range overlays {
+			if !OverlayTargets(o.Target, u) {
+				continue
+			}
+			if err := applyOverlay(u, o); err != nil {
+				return fmt.Errorf("overlays[%d] of %s %s: %w", i, u.GetKind(), u.GetName(), err)
+			}
+		}
+		return nil
+	}
+}
+
+// OverlayTargets is true when u is the object selected by target
+func OverlayTargets(target op.OverlayTarget, u *unstructured.Unstructured) bool {
+	return u.GetKind() == target.Kind && u.GetName() == target.Name &&
+		(target.APIVersion == "" || u.GetAPIVersion() == target.APIVersion) &&
+		(target.Namespace == "" || u.GetNamespace() == target.Namespace)
+}
+
+// OverlayPatch returns the patch of o as JSON, or an error when it is not a
+// valid patch of its type
+func OverlayPatch(o op.Overlay) ([]byte, error) {
+	if strings.TrimSpace(o.Patch) == "" {
+		return nil, fmt.Errorf("the patch is empty")
+	}
+	patch, err := yaml.YAMLToJSON([]byte(o.Patch))
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Type {
+	case op.JSONPatchOverlay:
+		if _, err := jsonpatch.DecodePatch(patch); err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: %w", err)
+		}
+	case op.StrategicMergeOverlay, "":
+		if err := json.Unmarshal(patch, &map[string]interface{}{}); err != nil {
+			return nil, fmt.Errorf("a strategic merge patch is an object: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown patch type %q", o.Type)
+	}
+	return patch, nil
+}
+
+func applyOverlay(u *unstructured.Unstructured, o op.Overlay) error {
+	patch, err := OverlayPatch(o)
+	if err != nil {
+		return err
+	}
+	original, err := json.Marshal(u.Object)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	if o.Type == op.JSONPatchOverlay {
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return err
+		}
+		patched, err = ops.Apply(original)
+		if err != nil {
+			return err
+		}
+	} else {
+		// the kinds without a Go type, like the Tekton resources, have no
+		// patch strategies so they are merged as plain JSON
+		typed, err := scheme.Scheme.New(u.GroupVersionKind())
+		switch {
+		case runtime.IsNotRegisteredError(err):
+			patched, err = jsonpatch.MergePatch(original, patch)
+		case err == nil:
+			patched, err = strategicpatch.StrategicMergePatch(original, patch, typed)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(patched, &obj); err != nil {
+		return err
+	}
+	u.SetUnstructuredContent(obj)
+	return nil
+}
@@ -0,0 +1,126 @@
+package transform
+
+import (
+	"path"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestOverlays(t *testing.T) {
+	deployment := op.OverlayTarget{APIVersion: "apps/v1", Kind: "Deployment", Name: "controller"}
+	task := op.OverlayTarget{Kind: "ClusterTask", Name: "buildah"}
+
+	t.Run("strategic_merge_of_typed_kind", func(t *testing.T) {
+		manifest, err := mf.ManifestFrom(mf.Recursive(path.Join("testdata", "test-replace-image.yaml")))
+		assertNoEror(t, err)
+		newManifest, err := manifest.Transform(Overlays([]op.Overlay{{
+			Target: deployment,
+			Patch: `
+spec:
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        readinessProbe:
+          timeoutSeconds: 10
+`,
+		}}))
+		assertNoEror(t, err)
+
+		d := &appsv1.Deployment{}
+		assertNoEror(t, runtime.DefaultUnstructuredConverter.FromUnstructured(newManifest.Resources()[0].Object, d))
+		containers := d.Spec.Template.Spec.Containers
+		// the containers are merged by name
+		if len(containers) != 2 || containers[0].Image != "busybox" {
+			t.Fatalf("expected both containers to be kept, got %v", containers)
+		}
+		if probe := containers[1].ReadinessProbe; probe == nil || probe.TimeoutSeconds != 10 {
+			t.Errorf("expected the probe timeout of the sidecar to be set, got %v", probe)
+		}
+	})
+
+	t.Run("strategic_merge_of_untyped_kind", func(t *testing.T) {
+		manifest, err := mf.ManifestFrom(mf.Recursive(path.Join("testdata", "test-replace-addon-image.yaml")))
+		assertNoEror(t, err)
+		newManifest, err := manifest.Transform(Overlays([]op.Overlay{{
+			Target: task,
+			Type:   op.StrategicMergeOverlay,
+			Patch:  `{"metadata": {"annotations": {"team": "ci"}}}`,
+		}}))
+		assertNoEror(t, err)
+		if got := newManifest.Resources()[0].GetAnnotations()["team"]; got != "ci" {
+			t.Errorf("expected the annotation to be merged, got %q", got)
+		}
+	})
+
+	t.Run("json_patch", func(t *testing.T) {
+		manifest, err := mf.ManifestFrom(mf.Recursive(path.Join("testdata", "test-replace-addon-image.yaml")))
+		assertNoEror(t, err)
+		newManifest, err := manifest.Transform(Overlays([]op.Overlay{{
+			Target: task,
+			Type:   op.JSONPatchOverlay,
+			Patch:  "- op: replace\n  path: /spec/params/0/default\n  value: registry.local/buildah:v1.11.0\n",
+		}}))
+		assertNoEror(t, err)
+		params, _, _ := unstructured.NestedSlice(newManifest.Resources()[0].Object, "spec", "params")
+		if got := params[0].(map[string]interface{})["default"]; got != "registry.local/buildah:v1.11.0" {
+			t.Errorf("expected the default of the first param to be replaced, got %v", got)
+		}
+	})
+
+	t.Run("other_objects", func(t *testing.T) {
+		manifest, err := mf.ManifestFrom(mf.Recursive(path.Join("testdata", "test-replace-image.yaml")))
+		assertNoEror(t, err)
+		for _, target := range []op.OverlayTarget{
+			{Kind: "Deployment", Name: "webhook"},
+			{APIVersion: "apps/v1beta1", Kind: "Deployment", Name: "controller"},
+			{Kind: "Deployment", Namespace: "other", Name: "controller"},
+		} {
+			newManifest, err := manifest.Transform(Overlays([]op.Overlay{{Target: target, Patch: "metadata: {labels: {team: ci}}"}}))
+			assertNoEror(t, err)
+			if labels := newManifest.Resources()[0].GetLabels(); labels != nil {
+				t.Errorf("expected %+v not to target the deployment, got labels %v", target, labels)
+			}
+		}
+	})
+
+	t.Run("failing_patch", func(t *testing.T) {
+		manifest, err := mf.ManifestFrom(mf.Recursive(path.Join("testdata", "test-replace-addon-image.yaml")))
+		assertNoEror(t, err)
+		_, err = manifest.Transform(Overlays([]op.Overlay{{
+			Target: task,
+			Type:   op.JSONPatchOverlay,
+			Patch:  `[{"op": "remove", "path": "/spec/missing"}]`,
+		}}))
+		if err == nil {
+			t.Error("expected the removal of a missing field to fail")
+		}
+	})
+}
+
+func TestOverlayPatch(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		overlay op.Overlay
+		valid   bool
+	}{
+		{"strategic", op.Overlay{Patch: "metadata:\n  labels:\n    team: ci\n"}, true},
+		{"json", op.Overlay{Type: op.JSONPatchOverlay, Patch: `[{"op": "add", "path": "/metadata/labels", "value": {}}]`}, true},
+		{"empty", op.Overlay{Patch: " \n"}, false},
+		{"strategic_list", op.Overlay{Patch: "- team"}, false},
+		{"json_object", op.Overlay{Type: op.JSONPatchOverlay, Patch: "op: add"}, false},
+		{"unknown_type", op.Overlay{Type: "merge", Patch: "metadata: {}"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := OverlayPatch(tc.overlay)
+			if valid := err == nil; valid != tc.valid {
+				t.Errorf("expected valid %t, got error %v", tc.valid, err)
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package transform
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -228,6 +230,247 @@ func DeploymentImages(images map[string]string) mf.Transformer {
 	}
 }
 
+// DeploymentOverrides applies the settings of overrides to the Deployments
+// named by its keys
+func DeploymentOverrides(overrides map[string]op.DeploymentOverride) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Deployment" {
+			return nil
+		}
+		override, ok := overrides[u.GetName()]
+		if !ok {
+			return nil
+		}
+
+		d := &appsv1.Deployment{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, d)
+		if err != nil {
+			return err
+		}
+
+		applyDeploymentOverride(d, override)
+
+		unstrObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d)
+		if err != nil {
+			return err
+		}
+		u.SetUnstructuredContent(unstrObj)
+
+		return nil
+	}
+}
+
+func applyDeploymentOverride(d *appsv1.Deployment, override op.DeploymentOverride) {
+	if override.Replicas != nil {
+		replicas := *override.Replicas
+		d.Spec.Replicas = &replicas
+	}
+
+	pod := &d.Spec.Template.Spec
+	if override.NodeSelector != nil {
+		pod.NodeSelector = override.NodeSelector
+	}
+	if override.Tolerations != nil {
+		pod.Tolerations = override.Tolerations
+	}
+	if override.Affinity != nil {
+		pod.Affinity = override.Affinity
+	}
+	if override.PriorityClassName != "" {
+		pod.PriorityClassName = override.PriorityClassName
+	}
+
+	for i := range pod.Containers {
+		container := &pod.Containers[i]
+		if override.Resources != nil {
+			container.Resources = *override.Resources
+		}
+		container.Env = mergeEnv(container.Env, override.Env)
+	}
+}
+
+// mergeEnv sets the variables of extra on env, replacing the ones of the same name
+func mergeEnv(env []corev1.EnvVar, extra []corev1.EnvVar) []corev1.EnvVar {
+	for _, e := range extra {
+		replaced := false
+		for i := range env {
+			if env[i].Name == e.Name {
+				env[i] = e
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			env = append(env, e)
+		}
+	}
+	return env
+}
+
+// TrustedCA describes the ConfigMap holding a CA bundle to be trusted by the
+// containers of a Deployment
+type TrustedCA struct {
+	// ConfigMap and Key locate the bundle, which is mounted on MountPath
+	ConfigMap string
+	Key       string
+	MountPath string
+
+	// CertDirs are the directories of the system certificates, which are
+	// still trusted along with the bundle
+	CertDirs string
+
+	// Hash of the bundle, annotated on the pod template with HashAnnotation
+	// so the pods restart when the bundle changes
+	HashAnnotation string
+	Hash           string
+}
+
+// DeploymentProxy sets the env vars of the proxy on every container of the
+// Deployments and, when ca is set, mounts its bundle and adds it to the
+// directories Go reads trusted certificates from
+func DeploymentProxy(env []corev1.EnvVar, ca *TrustedCA) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Deployment" {
+			return nil
+		}
+
+		d := &appsv1.Deployment{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, d)
+		if err != nil {
+			return err
+		}
+
+		containerEnv := env
+		if ca != nil {
+			injectTrustedCA(d, ca)
+			containerEnv = mergeEnv(append([]corev1.EnvVar{}, env...),
+				[]corev1.EnvVar{{Name: "SSL_CERT_DIR", Value: ca.MountPath + ":" + ca.CertDirs}})
+		}
+		containers := d.Spec.Template.Spec.Containers
+		for i := range containers {
+			containers[i].Env = mergeEnv(containers[i].Env, containerEnv)
+		}
+
+		unstrObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d)
+		if err != nil {
+			return err
+		}
+		u.SetUnstructuredContent(unstrObj)
+		return nil
+	}
+}
+
+func injectTrustedCA(d *appsv1.Deployment, ca *TrustedCA) {
+	optional := true
+	pod := &d.Spec.Template.Spec
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: ca.ConfigMap,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ca.ConfigMap},
+				Items:                []corev1.KeyToPath{{Key: ca.Key, Path: ca.Key}},
+				Optional:             &optional,
+			},
+		},
+	})
+	for i := range pod.Containers {
+		pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name:      ca.ConfigMap,
+			MountPath: ca.MountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	annotations := d.Spec.Template.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ca.HashAnnotation] = ca.Hash
+	d.Spec.Template.SetAnnotations(annotations)
+}
+
+// maxLeaderElectionBuckets is the largest number of buckets the reconcilers
+// of the upstream controllers can be split into
+const maxLeaderElectionBuckets = 10
+
+// HighAvailability scales the named Deployments to replicas and spreads their
+// pods across nodes, raises the minimum of the autoscalers targeting them and
+// splits the leader election of the controllers into one bucket per replica
+func HighAvailability(replicas int32, deployments ...string) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		switch u.GetKind() {
+		case "Deployment":
+			if !ItemInSlice(u.GetName(), deployments) {
+				return nil
+			}
+			return highAvailabilityDeployment(u, replicas)
+		case "HorizontalPodAutoscaler":
+			target, _, _ := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "name")
+			if !ItemInSlice(target, deployments) {
+				return nil
+			}
+			return highAvailabilityAutoscaler(u, replicas)
+		case "ConfigMap":
+			if u.GetName() != "config-leader-election" {
+				return nil
+			}
+			buckets := replicas
+			if buckets > maxLeaderElectionBuckets {
+				buckets = maxLeaderElectionBuckets
+			}
+			return unstructured.SetNestedField(u.Object, strconv.Itoa(int(buckets)), "data", "buckets")
+		}
+		return nil
+	}
+}
+
+func highAvailabilityDeployment(u *unstructured.Unstructured, replicas int32) error {
+	d := &appsv1.Deployment{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, d)
+	if err != nil {
+		return err
+	}
+
+	d.Spec.Replicas = &replicas
+	pod := &d.Spec.Template.Spec
+	if pod.Affinity == nil {
+		pod.Affinity = &corev1.Affinity{}
+	}
+	if pod.Affinity.PodAntiAffinity == nil && d.Spec.Selector != nil {
+		pod.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: d.Spec.Selector.DeepCopy(),
+					TopologyKey:   corev1.LabelHostname,
+				},
+			}},
+		}
+	}
+
+	unstrObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d)
+	if err != nil {
+		return err
+	}
+	u.SetUnstructuredContent(unstrObj)
+	return nil
+}
+
+func highAvailabilityAutoscaler(u *unstructured.Unstructured, replicas int32) error {
+	min, _, _ := unstructured.NestedInt64(u.Object, "spec", "minReplicas")
+	if min < int64(replicas) {
+		min = int64(replicas)
+		if err := unstructured.SetNestedField(u.Object, min, "spec", "minReplicas"); err != nil {
+			return err
+		}
+	}
+	max, _, _ := unstructured.NestedInt64(u.Object, "spec", "maxReplicas")
+	if max < min {
+		return unstructured.SetNestedField(u.Object, min, "spec", "maxReplicas")
+	}
+	return nil
+}
+
 func replaceContainerImages(containers []corev1.Container, images map[string]string) {
 	for i, container := range containers {
 		name := formKey("", container.Name)
@@ -6,8 +6,11 @@ import (
 	"testing"
 
 	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
 	"github.com/tektoncd/operator/pkg/flag"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -414,6 +417,158 @@ func assertTaskImage(t *testing.T, resources []unstructured.Unstructured, name s
 	}
 }
 
+func TestDeploymentOverrides(t *testing.T) {
+	testData := path.Join("testdata", "test-replace-image.yaml")
+	replicas := int32(3)
+	overrides := map[string]op.DeploymentOverride{
+		"controller": {
+			Replicas:          &replicas,
+			NodeSelector:      map[string]string{"node-role.kubernetes.io/infra": ""},
+			Tolerations:       []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule}},
+			PriorityClassName: "system-cluster-critical",
+			Resources: &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			Env: []corev1.EnvVar{{Name: "HTTP_PROXY", Value: "http://proxy:3128"}},
+		},
+	}
+
+	t.Run("of_named_deployment", func(t *testing.T) {
+		manifest, err := mf.ManifestFrom(mf.Recursive(testData))
+		assertNoEror(t, err)
+		newManifest, err := manifest.Transform(DeploymentOverrides(overrides))
+		assertNoEror(t, err)
+
+		d := &appsv1.Deployment{}
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(newManifest.Resources()[0].Object, d)
+		assertNoEror(t, err)
+		pod := d.Spec.Template.Spec
+		if *d.Spec.Replicas != replicas {
+			t.Errorf("expected %d replicas, got %d", replicas, *d.Spec.Replicas)
+		}
+		if _, ok := pod.NodeSelector["node-role.kubernetes.io/infra"]; !ok {
+			t.Errorf("expected infra node selector, got %v", pod.NodeSelector)
+		}
+		if len(pod.Tolerations) != 1 || pod.PriorityClassName != "system-cluster-critical" {
+			t.Errorf("expected scheduling overrides, got %v %s", pod.Tolerations, pod.PriorityClassName)
+		}
+		for _, c := range pod.Containers {
+			if !c.Resources.Limits.Memory().Equal(resource.MustParse("1Gi")) {
+				t.Errorf("expected memory limit on container %s, got %v", c.Name, c.Resources.Limits)
+			}
+			if len(c.Env) != 1 || c.Env[0].Value != "http://proxy:3128" {
+				t.Errorf("expected env on container %s, got %v", c.Name, c.Env)
+			}
+		}
+	})
+
+	t.Run("ignore_other_deployments", func(t *testing.T) {
+		manifest, err := mf.ManifestFrom(mf.Recursive(testData))
+		assertNoEror(t, err)
+		newManifest, err := manifest.Transform(DeploymentOverrides(map[string]op.DeploymentOverride{"webhook": overrides["controller"]}))
+		assertNoEror(t, err)
+		assertEqual(t, newManifest.Resources(), manifest.Resources())
+	})
+}
+
+func TestHighAvailability(t *testing.T) {
+	testData := path.Join("testdata", "test-replace-image.yaml")
+	manifest, err := mf.ManifestFrom(mf.Recursive(testData))
+	assertNoEror(t, err)
+
+	hpa := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2beta1",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "controller"},
+		"spec": map[string]interface{}{
+			"minReplicas":    int64(1),
+			"maxReplicas":    int64(2),
+			"scaleTargetRef": map[string]interface{}{"name": "controller"},
+		},
+	}}
+	cm := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config-leader-election"},
+		"data":       map[string]interface{}{"resourceLock": "leases"},
+	}}
+	extra, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{hpa, cm}))
+	assertNoEror(t, err)
+
+	newManifest, err := manifest.Append(extra).Transform(HighAvailability(3, "controller"))
+	assertNoEror(t, err)
+	resources := newManifest.Resources()
+
+	d := &appsv1.Deployment{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(resources[0].Object, d)
+	assertNoEror(t, err)
+	if *d.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", *d.Spec.Replicas)
+	}
+	affinity := d.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.PodAntiAffinity == nil {
+		t.Fatalf("expected pod anti affinity, got %v", affinity)
+	}
+	if key := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey; key != corev1.LabelHostname {
+		t.Errorf("expected topology key %s, got %s", corev1.LabelHostname, key)
+	}
+
+	if min, _, _ := unstructured.NestedInt64(resources[1].Object, "spec", "minReplicas"); min != 3 {
+		t.Errorf("expected autoscaler min replicas 3, got %d", min)
+	}
+	if max, _, _ := unstructured.NestedInt64(resources[1].Object, "spec", "maxReplicas"); max != 3 {
+		t.Errorf("expected autoscaler max replicas 3, got %d", max)
+	}
+	assertConfigMapKeyValue(t, resources[2], "buckets", "3")
+}
+
+func TestDeploymentProxy(t *testing.T) {
+	testData := path.Join("testdata", "test-replace-image.yaml")
+	manifest, err := mf.ManifestFrom(mf.Recursive(testData))
+	assertNoEror(t, err)
+
+	env := []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}}
+	ca := &TrustedCA{
+		ConfigMap:      "config-trusted-cabundle",
+		Key:            "ca-bundle.crt",
+		MountPath:      "/etc/config-trusted-cabundle",
+		CertDirs:       "/etc/ssl/certs",
+		HashAnnotation: "operator.tekton.dev/trusted-ca-hash",
+		Hash:           "abc",
+	}
+	newManifest, err := manifest.Transform(DeploymentProxy(env, ca))
+	assertNoEror(t, err)
+
+	d := deploymentFor(t, newManifest.Resources()[0])
+	if d.Spec.Template.Annotations[ca.HashAnnotation] != "abc" {
+		t.Errorf("expected hash annotation, got %v", d.Spec.Template.Annotations)
+	}
+	if len(d.Spec.Template.Spec.Volumes) != 1 || d.Spec.Template.Spec.Volumes[0].ConfigMap.Name != ca.ConfigMap {
+		t.Errorf("expected trusted CA volume, got %v", d.Spec.Template.Spec.Volumes)
+	}
+	expected := []corev1.EnvVar{
+		{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+		{Name: "SSL_CERT_DIR", Value: "/etc/config-trusted-cabundle:/etc/ssl/certs"},
+	}
+	for _, c := range d.Spec.Template.Spec.Containers {
+		if !reflect.DeepEqual(c.Env, expected) {
+			t.Errorf("expected env %v on container %s, got %v", expected, c.Name, c.Env)
+		}
+		if len(c.VolumeMounts) != 1 || c.VolumeMounts[0].MountPath != ca.MountPath {
+			t.Errorf("expected trusted CA mount on container %s, got %v", c.Name, c.VolumeMounts)
+		}
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	env := []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}
+	merged := mergeEnv(env, []corev1.EnvVar{{Name: "B", Value: "3"}, {Name: "C", Value: "4"}})
+	expected := []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "3"}, {Name: "C", Value: "4"}}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+}
+
 func TestTransformManifest_InjectNamespaceRoleBindingSubjects(t *testing.T) {
 	resourceWithAnnotation := "testdata/inject-ns-rolebinding.yaml"
 	manifest, err := mf.ManifestFrom(mf.Recursive(resourceWithAnnotation))
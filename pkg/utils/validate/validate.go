@@ -6,9 +6,13 @@ import (
 
 	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
 	"k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1Options "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -52,6 +56,89 @@ func Deployment(ctx context.Context, c client.Client, name, namespace string) (b
 	return false, nil
 }
 
+// DeploymentReplicas returns the number of ready replicas of a deployment and
+// the number it desires
+func DeploymentReplicas(ctx context.Context, c client.Client, name, namespace string) (int32, int32, error) {
+	deployment := v1.Deployment{}
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	if err := c.Get(ctx, key, &deployment); err != nil {
+		return 0, 0, ignoreNotFound(err)
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ReadyReplicas, desired, nil
+}
+
+// podFailureReasons are the container waiting reasons that indicate a pod will
+// not become ready without intervention
+var podFailureReasons = []string{
+	"CrashLoopBackOff",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"ErrImagePull",
+	"ImagePullBackOff",
+	"InvalidImageName",
+}
+
+// PodFailures returns the reasons why pods of the Deployment name are failing
+// to start, e.g. ImagePullBackOff or CrashLoopBackOff. c should read from the
+// apiserver, so that the cache does not watch every Pod of the cluster
+func PodFailures(ctx context.Context, c client.Reader, name, namespace string) ([]string, error) {
+	deployment := v1.Deployment{}
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	err := c.Get(ctx, key, &deployment)
+	if err != nil {
+		return nil, ignoreNotFound(err)
+	}
+
+	selector, err := v1Options.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := corev1.PodList{}
+	err = c.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []string
+	for _, pod := range pods.Items {
+		statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.State.Waiting == nil || !contains(podFailureReasons, cs.State.Waiting.Reason) {
+				continue
+			}
+			failure := fmt.Sprintf("pod %s container %s: %s", pod.Name, cs.Name, cs.State.Waiting.Reason)
+			if cs.State.Waiting.Message != "" {
+				failure += " (" + cs.State.Waiting.Message + ")"
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return failures, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, v := range items {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
 func getDeploymentCondition(status v1.DeploymentStatus, condType v1.DeploymentConditionType) *v1.DeploymentCondition {
 	for i := range status.Conditions {
 		c := status.Conditions[i]
@@ -78,3 +165,79 @@ func CRD(config *rest.Config, crdName string) (bool, error) {
 		CustomResourceDefinitions().Get(crdName, v1Options.GetOptions{})
 	return err == nil, ignoreNotFound(err)
 }
+
+// Endpoints checks whether the Service name in namespace has at least one
+// ready address backing it. c should read from the apiserver, so that the
+// cache does not watch every Endpoints of the cluster
+func Endpoints(ctx context.Context, c client.Reader, name, namespace string) (bool, error) {
+	endpoints := corev1.Endpoints{}
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	err := c.Get(ctx, key, &endpoints)
+	if err != nil {
+		return false, ignoreNotFound(err)
+	}
+
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var crdGVK = schema.GroupVersionKind{
+	Group:   "apiextensions.k8s.io",
+	Version: "v1beta1",
+	Kind:    "CustomResourceDefinition",
+}
+
+// CRDEstablished checks whether the CustomResourceDefinition crdName exists
+// and reports the Established condition as True
+func CRDEstablished(ctx context.Context, c client.Client, crdName string) (bool, error) {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+
+	err := c.Get(ctx, client.ObjectKey{Name: crdName}, crd)
+	if err != nil {
+		return false, ignoreNotFound(err)
+	}
+
+	conditions, _, err := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	if err != nil {
+		return false, err
+	}
+	for _, cond := range conditions {
+		m, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if m["type"] == "Established" && m["status"] == "True" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// WebhookReachable does a dry-run create of obj to confirm that the admission
+// webhooks intercepting it respond. A rejection by the webhook still proves
+// that it is reachable, whereas failures calling it are reported as not ready
+func WebhookReachable(ctx context.Context, c client.Client, obj runtime.Object) (bool, error) {
+	err := c.Create(ctx, obj.DeepCopyObject(), client.DryRunAll)
+	switch {
+	case err == nil,
+		errors.IsAlreadyExists(err),
+		errors.IsInvalid(err),
+		errors.IsBadRequest(err):
+		return true, nil
+	case errors.IsInternalError(err),
+		errors.IsServiceUnavailable(err),
+		errors.IsTimeout(err),
+		errors.IsServerTimeout(err):
+		return false, nil
+	}
+	return false, err
+}
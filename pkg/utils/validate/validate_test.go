@@ -0,0 +1,146 @@
+package validate
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEndpoints(t *testing.T) {
+	t.Run("service with ready addresses", func(t *testing.T) {
+		ep := newEndpoints("tekton-pipelines-webhook", "openshift-pipelines", []corev1.EndpointAddress{{IP: "10.0.0.1"}})
+		cl := fake.NewFakeClientWithScheme(scheme.Scheme, ep)
+
+		ready, err := Endpoints(context.TODO(), cl, ep.Name, ep.Namespace)
+		assertNoError(t, err)
+		assertReady(t, ready, true)
+	})
+
+	t.Run("service without ready addresses", func(t *testing.T) {
+		ep := newEndpoints("tekton-pipelines-webhook", "openshift-pipelines", nil)
+		cl := fake.NewFakeClientWithScheme(scheme.Scheme, ep)
+
+		ready, err := Endpoints(context.TODO(), cl, ep.Name, ep.Namespace)
+		assertNoError(t, err)
+		assertReady(t, ready, false)
+	})
+
+	t.Run("missing endpoints", func(t *testing.T) {
+		cl := fake.NewFakeClientWithScheme(scheme.Scheme)
+
+		ready, err := Endpoints(context.TODO(), cl, "tekton-pipelines-webhook", "openshift-pipelines")
+		assertNoError(t, err)
+		assertReady(t, ready, false)
+	})
+}
+
+func TestCRDEstablished(t *testing.T) {
+	t.Run("established crd", func(t *testing.T) {
+		crd := newCRD("tasks.tekton.dev", "True")
+		cl := fake.NewFakeClientWithScheme(scheme.Scheme, crd)
+
+		ready, err := CRDEstablished(context.TODO(), cl, crd.GetName())
+		assertNoError(t, err)
+		assertReady(t, ready, true)
+	})
+
+	t.Run("crd not yet established", func(t *testing.T) {
+		crd := newCRD("tasks.tekton.dev", "False")
+		cl := fake.NewFakeClientWithScheme(scheme.Scheme, crd)
+
+		ready, err := CRDEstablished(context.TODO(), cl, crd.GetName())
+		assertNoError(t, err)
+		assertReady(t, ready, false)
+	})
+}
+
+func TestWebhookReachable(t *testing.T) {
+	probe := &unstructured.Unstructured{}
+	probe.SetAPIVersion("v1")
+	probe.SetKind("ConfigMap")
+	probe.SetName("probe")
+	probe.SetNamespace("openshift-pipelines")
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme)
+
+	ready, err := WebhookReachable(context.TODO(), cl, probe)
+	assertNoError(t, err)
+	assertReady(t, ready, true)
+}
+
+func TestDeploymentReplicas(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "tekton-pipelines-controller", Namespace: "openshift-pipelines"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, deployment)
+
+	ready, desired, err := DeploymentReplicas(context.TODO(), cl, deployment.Name, deployment.Namespace)
+	assertNoError(t, err)
+	if ready != 1 || desired != 2 {
+		t.Errorf("expected 1/2 replicas ready, got %d/%d", ready, desired)
+	}
+}
+
+func TestPodFailures(t *testing.T) {
+	labels := map[string]string{"app": "tekton-pipelines-controller"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "tekton-pipelines-controller", Namespace: "openshift-pipelines"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "tekton-pipelines-controller-1", Namespace: "openshift-pipelines", Labels: labels},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "controller",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+			}},
+		},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, deployment, pod)
+
+	failures, err := PodFailures(context.TODO(), cl, deployment.Name, deployment.Namespace)
+	assertNoError(t, err)
+	expected := "pod tekton-pipelines-controller-1 container controller: ImagePullBackOff"
+	if len(failures) != 1 || failures[0] != expected {
+		t.Errorf("expected failures [%s], got %v", expected, failures)
+	}
+}
+
+func newEndpoints(name, namespace string, addresses []corev1.EndpointAddress) *corev1.Endpoints {
+	return &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Subsets:    []corev1.EndpointSubset{{Addresses: addresses}},
+	}
+}
+
+func newCRD(name, established string) *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	crd.SetName(name)
+	_ = unstructured.SetNestedSlice(crd.Object, []interface{}{
+		map[string]interface{}{"type": "Established", "status": established},
+	}, "status", "conditions")
+	return crd
+}
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func assertReady(t *testing.T, got, expected bool) {
+	t.Helper()
+	if got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
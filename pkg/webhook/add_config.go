@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"github.com/tektoncd/operator/pkg/webhook/config"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, config.Add)
+}
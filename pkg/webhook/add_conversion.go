@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+// ConvertPath is the path the conversion webhook of the CRDs is served at
+const ConvertPath = "/convert"
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create webhooks and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, addConversion)
+}
+
+// addConversion serves the conversions between the versions of the CRDs, the
+// Hub and Convertible implementations of the types registered in the scheme
+// of the manager
+func addConversion(mgr manager.Manager) error {
+	mgr.GetWebhookServer().Register(ConvertPath, &conversion.Webhook{})
+	return nil
+}
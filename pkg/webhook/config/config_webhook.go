@@ -0,0 +1,237 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/pkg/utils/schedule"
+	"github.com/tektoncd/operator/pkg/utils/transform"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// paths the webhooks of Config are served at
+const (
+	MutatePath   = "/mutate-operator-tekton-dev-v1alpha1-config"
+	ValidatePath = "/validate-operator-tekton-dev-v1alpha1-config"
+)
+
+var (
+	log = logf.Log.WithName("webhook").WithName("config")
+
+	// reservedPrefixes are the prefixes of the namespaces owned by the
+	// platform, the pipelines may not be installed into them
+	reservedPrefixes = []string{"openshift-", "kube-"}
+)
+
+// Add registers the defaulting and validating webhooks of Config with the
+// webhook server of mgr
+func Add(mgr manager.Manager) error {
+	srv := mgr.GetWebhookServer()
+	srv.Register(MutatePath, &webhook.Admission{Handler: &defaulter{}})
+	srv.Register(ValidatePath, &webhook.Admission{Handler: &validator{client: mgr.GetClient()}})
+	return nil
+}
+
+// defaulter sets the defaults of the fields of a Config
+type defaulter struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &defaulter{}
+
+// InjectDecoder injects the decoder
+func (d *defaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle defaults the Config of req
+func (d *defaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cfg := &op.Config{}
+	if err := d.decoder.Decode(req, cfg); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	setDefaults(cfg)
+
+	current, err := json.Marshal(cfg)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, current)
+}
+
+// setDefaults sets the fields of cfg that are empty to their defaults
+func setDefaults(cfg *op.Config) {
+	if cfg.Spec.TargetNamespace == "" {
+		cfg.Spec.TargetNamespace = flag.TargetNamespace
+	}
+	if cfg.Spec.ManagementState == "" {
+		cfg.Spec.ManagementState = op.Managed
+	}
+	if ha := cfg.Spec.HighAvailability; ha != nil && ha.Replicas == 0 {
+		ha.Replicas = flag.DefaultHAReplicas
+	}
+	for i := range cfg.Spec.Overlays {
+		if cfg.Spec.Overlays[i].Type == "" {
+			cfg.Spec.Overlays[i].Type = op.StrategicMergeOverlay
+		}
+	}
+}
+
+// validator rejects the Configs the controller cannot install
+type validator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &validator{}
+
+// InjectDecoder injects the decoder
+func (v *validator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// Handle validates the Config of req
+func (v *validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cfg := &op.Config{}
+	if err := v.decoder.Decode(req, cfg); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	errs, err := v.validate(ctx, cfg)
+	if err != nil {
+		log.Error(err, "failed to validate config", "name", cfg.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(errs) > 0 {
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+	return admission.Allowed("")
+}
+
+// validate returns the errors of the name, the maintenance window, the
+// overlays, the v1beta1 only fields and the target namespace of cfg
+func (v *validator) validate(ctx context.Context, cfg *op.Config) (field.ErrorList, error) {
+	var errs field.ErrorList
+
+	name := field.NewPath("metadata", "name")
+	for _, msg := range validation.IsDNS1123Label(cfg.Name) {
+		errs = append(errs, field.Invalid(name, cfg.Name, msg+", the name is the value of the "+flag.LabelInstance+" label"))
+	}
+	errs = append(errs, validateMaintenanceWindow(cfg.Spec.MaintenanceWindow)...)
+	errs = append(errs, validateOverlays(cfg.Spec.Overlays)...)
+
+	target := field.NewPath("spec", "targetNamespace")
+	ns := cfg.Spec.TargetNamespace
+	if ns == "" {
+		return append(errs, field.Required(target, "the namespace the pipelines are installed into")), nil
+	}
+	for _, msg := range validation.IsDNS1123Label(ns) {
+		errs = append(errs, field.Invalid(target, ns, msg))
+	}
+	if reserved(ns) {
+		errs = append(errs, field.Invalid(target, ns,
+			"namespaces starting with "+strings.Join(reservedPrefixes, " or ")+" are reserved, except "+
+				flag.TargetNamespace+" and the namespaces starting with "+flag.TargetNamespace+"-"))
+	}
+	if len(errs) > 0 {
+		return errs, nil
+	}
+
+	// a namespace that does not exist is accepted: the release creates it,
+	// and a Config may be created before its namespace
+	existing := &corev1.Namespace{}
+	err := v.client.Get(ctx, types.NamespacedName{Name: ns}, existing)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return nil, err
+	case existing.Status.Phase == corev1.NamespaceTerminating:
+		errs = append(errs, field.Invalid(target, ns, "the namespace is being deleted"))
+	}
+
+	configs := &op.ConfigList{}
+	if err := v.client.List(ctx, configs); err != nil {
+		return nil, err
+	}
+	for _, other := range configs.Items {
+		if other.Name != cfg.Name && other.Spec.TargetNamespace == ns {
+			errs = append(errs, field.Duplicate(target, ns+" is already used by config "+other.Name))
+		}
+	}
+	return errs, nil
+}
+
+// validateMaintenanceWindow returns the errors of the schedule and the
+// duration of w
+func validateMaintenanceWindow(w *op.MaintenanceWindow) field.ErrorList {
+	if w == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	path := field.NewPath("spec", "maintenanceWindow")
+	if w.Duration.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("duration"), w.Duration.String(), "must be positive"))
+	}
+	if _, err := schedule.Parse(w.Schedule); err != nil {
+		errs = append(errs, field.Invalid(path.Child("schedule"), w.Schedule, err.Error()))
+	}
+	return errs
+}
+
+// validateOverlays returns the errors of the targets and the patches of
+// overlays. Whether the targets exist depends on the installed releases, so it
+// is reported in the status instead
+func validateOverlays(overlays []op.Overlay) field.ErrorList {
+	var errs field.ErrorList
+	for i, o := range overlays {
+		path := field.NewPath("spec", "overlays").Index(i)
+		if o.Target.Kind == "" {
+			errs = append(errs, field.Required(path.Child("target", "kind"), "the kind of the patched object"))
+		}
+		if o.Target.Name == "" {
+			errs = append(errs, field.Required(path.Child("target", "name"), "the name of the patched object"))
+		}
+		switch o.Type {
+		case "", op.StrategicMergeOverlay, op.JSONPatchOverlay:
+		default:
+			errs = append(errs, field.NotSupported(path.Child("type"), o.Type,
+				[]string{string(op.JSONPatchOverlay), string(op.StrategicMergeOverlay)}))
+			continue
+		}
+		if _, err := transform.OverlayPatch(o); err != nil {
+			errs = append(errs, field.Invalid(path.Child("patch"), o.Patch, err.Error()))
+		}
+	}
+	return errs
+}
+
+// reserved is true for the namespaces of the platform, other than the default
+// target namespace of the operator and the namespaces of the other instances
+// named after it, such as openshift-pipelines-canary
+func reserved(ns string) bool {
+	if ns == flag.TargetNamespace || strings.HasPrefix(ns, flag.TargetNamespace+"-") {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(ns, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/apis/operator/v1beta1"
+	"github.com/tektoncd/operator/pkg/flag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidate(t *testing.T) {
+	terminating := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "leaving"},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+	existing := newConfig("other", "pipelines")
+
+	for _, tc := range []struct {
+		name   string
+		config *op.Config
+		errors []string
+	}{
+		{name: "default", config: newConfig(flag.ClusterCRName, flag.TargetNamespace)},
+		{name: "new namespace", config: newConfig(flag.ClusterCRName, "ci")},
+		{name: "update", config: newConfig("other", "pipelines")},
+		{name: "invalid name", config: newConfig("Cluster", "ci"), errors: []string{"metadata.name"}},
+		{name: "no namespace", config: newConfig(flag.ClusterCRName, ""), errors: []string{"Required value"}},
+		{name: "invalid namespace", config: newConfig(flag.ClusterCRName, "CI"), errors: []string{"spec.targetNamespace"}},
+		{name: "openshift namespace", config: newConfig(flag.ClusterCRName, "openshift-config"), errors: []string{"reserved"}},
+		{name: "instance namespace", config: newConfig("canary", "openshift-pipelines-canary")},
+		{name: "kube namespace", config: newConfig(flag.ClusterCRName, "kube-system"), errors: []string{"reserved"}},
+		{name: "terminating namespace", config: newConfig(flag.ClusterCRName, "leaving"), errors: []string{"being deleted"}},
+		{name: "used namespace", config: newConfig(flag.ClusterCRName, "pipelines"), errors: []string{"Duplicate value"}},
+		{name: "maintenance window", config: withMaintenanceWindow(newConfig(flag.ClusterCRName, "ci"), "0 22 * * mon-fri", 2*time.Hour)},
+		{name: "invalid maintenance window", config: withMaintenanceWindow(newConfig(flag.ClusterCRName, "ci"), "0 25 * * *", 0),
+			errors: []string{"spec.maintenanceWindow.duration", "spec.maintenanceWindow.schedule"}},
+		{name: "overlays", config: withOverlays(newConfig(flag.ClusterCRName, "ci"),
+			op.Overlay{Target: op.OverlayTarget{Kind: "Service", Name: "tekton-pipelines-webhook"}, Patch: "metadata: {annotations: {team: ci}}"},
+			op.Overlay{Target: op.OverlayTarget{Kind: "Deployment", Name: "tekton-pipelines-controller"}, Type: op.JSONPatchOverlay,
+				Patch: `[{"op": "replace", "path": "/spec/replicas", "value": 2}]`},
+		)},
+		{name: "invalid overlays", config: withOverlays(newConfig(flag.ClusterCRName, "ci"),
+			op.Overlay{Target: op.OverlayTarget{Name: "tekton-pipelines-webhook"}, Patch: "- team"},
+			op.Overlay{Target: op.OverlayTarget{Kind: "Service", Name: "tekton-pipelines-webhook"}, Type: "merge", Patch: "{}"},
+		), errors: []string{"spec.overlays[0].target.kind", "spec.overlays[0].patch", "spec.overlays[1].type"}},
+		{name: "v1beta1", config: fromV1beta1(v1beta1.ConfigSpec{TargetNamespace: "ci"})},
+	} {
+		v := &validator{client: newClient(terminating, existing)}
+		errs, err := v.validate(context.TODO(), tc.config)
+		if err != nil {
+			t.Fatalf("%s: failed to validate: %v", tc.name, err)
+		}
+		if len(errs) != len(tc.errors) {
+			t.Errorf("%s: expected %d errors, got %v", tc.name, len(tc.errors), errs)
+			continue
+		}
+		for i, e := range errs {
+			if !strings.Contains(e.Error(), tc.errors[i]) {
+				t.Errorf("%s: expected an error with %q, got %q", tc.name, tc.errors[i], e.Error())
+			}
+		}
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	cfg := newConfig(flag.ClusterCRName, "")
+	cfg.Spec.HighAvailability = &op.HighAvailability{}
+	setDefaults(cfg)
+	if cfg.Spec.TargetNamespace != flag.TargetNamespace {
+		t.Errorf("expected target namespace %s, got %s", flag.TargetNamespace, cfg.Spec.TargetNamespace)
+	}
+	if cfg.Spec.HighAvailability.Replicas != flag.DefaultHAReplicas {
+		t.Errorf("expected %d replicas, got %d", flag.DefaultHAReplicas, cfg.Spec.HighAvailability.Replicas)
+	}
+	if cfg.Spec.ManagementState != op.Managed {
+		t.Errorf("expected management state %s, got %s", op.Managed, cfg.Spec.ManagementState)
+	}
+
+	cfg = withOverlays(newConfig(flag.ClusterCRName, "ci"), op.Overlay{Patch: "{}"}, op.Overlay{Type: op.JSONPatchOverlay, Patch: "[]"})
+	setDefaults(cfg)
+	if cfg.Spec.Overlays[0].Type != op.StrategicMergeOverlay || cfg.Spec.Overlays[1].Type != op.JSONPatchOverlay {
+		t.Errorf("expected overlays to default to strategic merge patches, got %+v", cfg.Spec.Overlays)
+	}
+	cfg.Spec.Overlays = nil
+	if cfg.Spec.TargetNamespace != "ci" || cfg.Spec.HighAvailability != nil {
+		t.Errorf("expected the fields that are set to be kept, got %+v", cfg.Spec)
+	}
+}
+
+func withMaintenanceWindow(cfg *op.Config, schedule string, duration time.Duration) *op.Config {
+	cfg.Spec.MaintenanceWindow = &op.MaintenanceWindow{Schedule: schedule, Duration: metav1.Duration{Duration: duration}}
+	return cfg
+}
+
+func withOverlays(cfg *op.Config, overlays ...op.Overlay) *op.Config {
+	cfg.Spec.Overlays = overlays
+	return cfg
+}
+
+func fromV1beta1(spec v1beta1.ConfigSpec) *op.Config {
+	cfg := &op.Config{}
+	beta := &v1beta1.Config{ObjectMeta: metav1.ObjectMeta{Name: flag.ClusterCRName}, Spec: spec}
+	if err := beta.ConvertTo(cfg); err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func newConfig(name, ns string) *op.Config {
+	return &op.Config{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       op.ConfigSpec{TargetNamespace: ns},
+	}
+}
+
+func newClient(objs ...runtime.Object) client.Client {
+	s := scheme.Scheme
+	s.AddKnownTypes(op.SchemeGroupVersion, &op.Config{}, &op.ConfigList{})
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
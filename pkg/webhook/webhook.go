@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/tektoncd/operator/pkg/flag"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("webhook")
+
+// AddToManagerFuncs is a list of functions to add all Webhooks to the Manager
+var AddToManagerFuncs []func(manager.Manager) error
+
+// AddToManager adds all Webhooks to the Manager. The webhooks are not served
+// when no serving certificate is mounted, e.g. when the operator runs locally
+func AddToManager(m manager.Manager) error {
+	cert := filepath.Join(flag.WebhookCertDir, "tls.crt")
+	if _, err := os.Stat(cert); err != nil {
+		log.Info("admission webhooks disabled, no serving certificate", "cert", cert)
+		return nil
+	}
+
+	for _, f := range AddToManagerFuncs {
+		if err := f(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
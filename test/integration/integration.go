@@ -0,0 +1,425 @@
+// Package integration runs the controllers against a local kube-apiserver and
+// etcd started by envtest. There is no kube-controller-manager, kubelet or
+// OpenShift API in that control plane, so the package simulates the parts of
+// the cluster the controllers wait for: Deployments become ready, Services
+// get endpoints, pods fail to pull their images on demand and foreground
+// deletions complete.
+//
+// The binaries are read from KUBEBUILDER_ASSETS, see `make test-integration`.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/operator/pkg/apis"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// Interval is the period at which the simulated cluster updates the
+	// objects and conditions are polled
+	Interval = 250 * time.Millisecond
+
+	// Timeout bounds the wait for a condition. The controllers requeue some
+	// phases after 15 seconds
+	Timeout = 5 * time.Minute
+
+	// foregroundDeletion is the finalizer of the objects deleted with the
+	// foreground propagation policy, removed by the garbage collector
+	foregroundDeletion = "foregroundDeletion"
+)
+
+// Env is a local control plane running the operator CRDs
+type Env struct {
+	// Config connects to the kube-apiserver
+	Config *rest.Config
+
+	// Client reads from and writes to the kube-apiserver directly, without
+	// the cache of the manager
+	Client client.Client
+
+	// Manager is started by StartManager, after the controllers under test
+	// have been added to it, and replaced by RestartManager
+	Manager manager.Manager
+
+	// Root is the root of the repository, the working directory of the
+	// tests so that the default resource paths of the operator resolve
+	Root string
+
+	env     *envtest.Environment
+	wd      string
+	stopMgr chan struct{}
+	mgrDone chan struct{}
+	stopSim chan struct{}
+}
+
+// Start starts a control plane with the CRDs of deploy/crds and of the
+// OpenShift APIs in testdata/crds, and changes the working directory to the
+// root of the repository until Stop
+func Start(t *testing.T) *Env {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := findRoot(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkAssets(); err != nil {
+		t.Fatal(err)
+	}
+
+	env := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join(root, "deploy", "crds"),
+			filepath.Join(root, "test", "integration", "testdata", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("failed to start the control plane: %v", err)
+	}
+	e := &Env{Config: cfg, Root: root, env: env, wd: wd}
+
+	if e.Manager, err = newManager(cfg); err != nil {
+		e.Stop(t)
+		t.Fatal(err)
+	}
+	e.Client, err = client.New(cfg, client.Options{Scheme: e.Manager.GetScheme()})
+	if err != nil {
+		e.Stop(t)
+		t.Fatalf("failed to create the client: %v", err)
+	}
+
+	if err := os.Chdir(root); err != nil {
+		e.Stop(t)
+		t.Fatal(err)
+	}
+
+	// the edit cluster role is created by the kube-controller-manager
+	e.Create(t, &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "edit"},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"tekton.dev"},
+			Resources: []string{"*"},
+			Verbs:     []string{"*"},
+		}},
+	})
+	return e
+}
+
+// StartManager starts the manager and waits for its cache to sync
+func (e *Env) StartManager(t *testing.T) {
+	t.Helper()
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	e.stopMgr, e.mgrDone = stop, done
+	go func() {
+		defer close(done)
+		if err := e.Manager.Start(stop); err != nil {
+			t.Errorf("manager stopped: %v", err)
+		}
+	}()
+	if !e.Manager.GetCache().WaitForCacheSync(stop) {
+		t.Fatal("failed to sync the cache of the manager")
+	}
+}
+
+// RestartManager stops the manager and replaces it with a new one, without
+// controllers, like a restart of the operator e.g. on upgrade
+func (e *Env) RestartManager(t *testing.T) {
+	t.Helper()
+
+	e.stopManager()
+	mgr, err := newManager(e.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Manager = mgr
+}
+
+func (e *Env) stopManager() {
+	if e.stopMgr == nil {
+		return
+	}
+	close(e.stopMgr)
+	<-e.mgrDone
+	e.stopMgr, e.mgrDone = nil, nil
+}
+
+func newManager(cfg *rest.Config) (manager.Manager, error) {
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the manager: %v", err)
+	}
+	if err := apis.AddToScheme(mgr.GetScheme()); err != nil {
+		return nil, fmt.Errorf("failed to register the operator apis: %v", err)
+	}
+	return mgr, nil
+}
+
+// Stop stops the simulation, the manager and the control plane, and restores
+// the working directory
+func (e *Env) Stop(t *testing.T) {
+	t.Helper()
+
+	if e.stopSim != nil {
+		close(e.stopSim)
+		e.stopSim = nil
+	}
+	e.stopManager()
+	if err := e.env.Stop(); err != nil {
+		t.Errorf("failed to stop the control plane: %v", err)
+	}
+	if err := os.Chdir(e.wd); err != nil {
+		t.Error(err)
+	}
+}
+
+// Create creates obj, which may already exist
+func (e *Env) Create(t *testing.T, obj runtime.Object) {
+	t.Helper()
+	if err := e.Client.Create(context.TODO(), obj); err != nil && !errors.IsAlreadyExists(err) {
+		t.Fatalf("failed to create %T: %v", obj, err)
+	}
+}
+
+// CreateNamespace creates the namespace name with the given annotations
+func (e *Env) CreateNamespace(t *testing.T, name string, annotations map[string]string) *corev1.Namespace {
+	t.Helper()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}}
+	e.Create(t, ns)
+	return ns
+}
+
+// SimulateCluster plays the part of the kube-controller-manager and the
+// kubelet in namespace until Stop: Deployments report their replicas as
+// ready, Services get an endpoint and the foreground deletion of Deployments
+// and Services completes
+func (e *Env) SimulateCluster(namespace string) {
+	if e.stopSim != nil {
+		close(e.stopSim)
+	}
+	e.stopSim = make(chan struct{})
+	go wait.Until(func() {
+		if err := e.simulate(context.TODO(), namespace); err != nil && !errors.IsConflict(err) {
+			fmt.Fprintf(os.Stderr, "simulated cluster: %v\n", err)
+		}
+	}, Interval, e.stopSim)
+}
+
+func (e *Env) simulate(ctx context.Context, namespace string) error {
+	deployments := &appsv1.DeploymentList{}
+	if err := e.Client.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if d.DeletionTimestamp != nil {
+			if err := e.completeDeletion(ctx, d); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.deploymentReady(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	services := &corev1.ServiceList{}
+	if err := e.Client.List(ctx, services, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range services.Items {
+		s := &services.Items[i]
+		if s.DeletionTimestamp != nil {
+			if err := e.completeDeletion(ctx, s); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.serviceEndpoints(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deploymentReady reports every replica of d as updated and available, unless
+// one of its pods is failing
+func (e *Env) deploymentReady(ctx context.Context, d *appsv1.Deployment) error {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	pods := &corev1.PodList{}
+	selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	if err := e.Client.List(ctx, pods, client.InNamespace(d.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return err
+	}
+	ready := replicas
+	if len(pods.Items) > 0 {
+		ready = 0
+	}
+
+	status := appsv1.DeploymentStatus{
+		ObservedGeneration: d.Generation,
+		Replicas:           replicas,
+		UpdatedReplicas:    replicas,
+		ReadyReplicas:      ready,
+		AvailableReplicas:  ready,
+	}
+	if d.Status.ObservedGeneration == status.ObservedGeneration && d.Status.Replicas == status.Replicas &&
+		d.Status.UpdatedReplicas == status.UpdatedReplicas && d.Status.ReadyReplicas == status.ReadyReplicas &&
+		d.Status.AvailableReplicas == status.AvailableReplicas {
+		return nil
+	}
+	d.Status = status
+	return e.Client.Status().Update(ctx, d)
+}
+
+// serviceEndpoints gives s a ready address listening on its ports
+func (e *Env) serviceEndpoints(ctx context.Context, s *corev1.Service) error {
+	ep := &corev1.Endpoints{}
+	err := e.Client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, ep)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	subset := corev1.EndpointSubset{
+		Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+	}
+	for _, p := range s.Spec.Ports {
+		port := p.Port
+		if p.TargetPort.Type == intstr.Int && p.TargetPort.IntVal != 0 {
+			port = p.TargetPort.IntVal
+		}
+		subset.Ports = append(subset.Ports, corev1.EndpointPort{Name: p.Name, Port: port, Protocol: p.Protocol})
+	}
+	ep = &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: s.Name, Namespace: s.Namespace},
+		Subsets:    []corev1.EndpointSubset{subset},
+	}
+	return e.Client.Create(ctx, ep)
+}
+
+// completeDeletion removes the finalizer the garbage collector would remove
+// once the dependents of obj are gone; the simulated cluster has none
+func (e *Env) completeDeletion(ctx context.Context, obj runtime.Object) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	finalizers := m.GetFinalizers()
+	var kept []string
+	for _, f := range finalizers {
+		if f != foregroundDeletion {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == len(finalizers) {
+		return nil
+	}
+	m.SetFinalizers(kept)
+	return ignoreNotFound(e.Client.Update(ctx, obj))
+}
+
+// FailPods creates a pod of the Deployment name in namespace whose container
+// waits with reason, e.g. ImagePullBackOff. The simulated cluster no longer
+// reports the Deployment as ready while the pod exists
+func (e *Env) FailPods(t *testing.T, namespace, name, reason string) {
+	t.Helper()
+
+	d := &appsv1.Deployment{}
+	e.Eventually(t, "deployment "+name+" to be created", func() (bool, error) {
+		err := e.Client.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, d)
+		return err == nil, ignoreNotFound(err)
+	})
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-failing",
+			Namespace: namespace,
+			Labels:    d.Spec.Selector.MatchLabels,
+		},
+		Spec: corev1.PodSpec{Containers: d.Spec.Template.Spec.Containers},
+	}
+	e.Create(t, pod)
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  pod.Spec.Containers[0].Name,
+		Image: pod.Spec.Containers[0].Image,
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+	}}
+	if err := e.Client.Status().Update(context.TODO(), pod); err != nil {
+		t.Fatalf("failed to update the status of pod %s: %v", pod.Name, err)
+	}
+}
+
+// Eventually polls condition until it is true and fails the test when it is
+// not within Timeout or returns an error
+func (e *Env) Eventually(t *testing.T, what string, condition wait.ConditionFunc) {
+	t.Helper()
+	if err := wait.PollImmediate(Interval, Timeout, condition); err != nil {
+		t.Fatalf("failed waiting for %s: %v", what, err)
+	}
+}
+
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// findRoot returns the first parent of dir holding go.mod
+func findRoot(dir string) (string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("no go.mod found above %s", dir)
+		}
+	}
+}
+
+// checkAssets fails early with a hint when the control plane binaries are
+// missing, rather than with the timeout of envtest
+func checkAssets() error {
+	bin := os.Getenv("TEST_ASSET_KUBE_APISERVER")
+	if bin == "" {
+		dir := os.Getenv("KUBEBUILDER_ASSETS")
+		if dir == "" {
+			dir = "/usr/local/kubebuilder/bin"
+		}
+		bin = filepath.Join(dir, "kube-apiserver")
+	}
+	if _, err := os.Stat(bin); err != nil {
+		return fmt.Errorf("kube-apiserver not found, run `make test-integration` or set KUBEBUILDER_ASSETS: %v", err)
+	}
+	return nil
+}
@@ -39,7 +39,7 @@ func ValidateDefaultSA(t *testing.T) {
 }
 
 // ValidateSCCRoleBinding validates that tekton controller creates
-// the cluster rolebinding for anyuid
+// the rolebinding for pipelines-scc
 func ValidateSCCRoleBinding(t *testing.T) {
 	ctx := test.NewContext(t)
 	defer ctx.Cleanup()
@@ -48,10 +48,10 @@ func ValidateSCCRoleBinding(t *testing.T) {
 	err := helpers.WaitForClusterCRStatus(t, flag.ClusterCRName, op.InstalledStatus)
 	helpers.AssertNoError(t, err)
 
-	helpers.WaitForRolebinding(t, "default", flag.PipelineAnyuid)
+	helpers.WaitForRolebinding(t, "default", flag.PipelinesSCCRoleBinding)
 
 	// Create a namespace
-	newNs := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foobar-rb-anyuid"}}
+	newNs := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foobar-rb-scc"}}
 	ns, err := test.Global.KubeClient.CoreV1().Namespaces().Create(&newNs)
 	// cleanup
 	defer func() {
@@ -61,11 +61,11 @@ func ValidateSCCRoleBinding(t *testing.T) {
 	if !apierrors.IsAlreadyExists(err) {
 		helpers.AssertNoError(t, err)
 	}
-	helpers.WaitForRolebinding(t, ns.Name, flag.PipelineAnyuid)
+	helpers.WaitForRolebinding(t, ns.Name, flag.PipelinesSCCRoleBinding)
 }
 
 // ValidateClusterRole validates that tekton controller creates
-// the cluster role for anyuid
+// the cluster role for pipelines-scc
 func ValidateClusterRole(t *testing.T) {
 	ctx := test.NewContext(t)
 	defer ctx.Cleanup()
@@ -74,5 +74,5 @@ func ValidateClusterRole(t *testing.T) {
 	err := helpers.WaitForClusterCRStatus(t, flag.ClusterCRName, op.InstalledStatus)
 	helpers.AssertNoError(t, err)
 
-	helpers.WaitForClusterRole(t, flag.PipelineAnyuid)
+	helpers.WaitForClusterRole(t, flag.PipelinesSCCRole)
 }
@@ -0,0 +1,8 @@
+// Package upgrade tests upgrades of the operator. The tests install the
+// payload of the previous release recorded in testdata/previous with the
+// config controller, then restart the controller with the resources of
+// deploy/resources as a new operator version would.
+//
+// The tests are built with the integration tag and run against the local
+// control plane of the test/integration package.
+package upgrade
@@ -0,0 +1,231 @@
+//go:build integration
+// +build integration
+
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	op "github.com/tektoncd/operator/pkg/apis/operator/v1alpha1"
+	"github.com/tektoncd/operator/pkg/controller/config"
+	"github.com/tektoncd/operator/pkg/flag"
+	"github.com/tektoncd/operator/test/integration"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	previousVersion         = "v1.0.0"
+	previousPipelineRelease = "v0.17.3"
+	previousTriggersRelease = "v0.7.0"
+
+	currentVersion         = "v1.1.0"
+	currentPipelineRelease = "v0.18.0"
+	currentTriggersRelease = "v0.8.1"
+
+	pipelineReleaseLabel = "pipeline.tekton.dev/release"
+	triggersReleaseLabel = "triggers.tekton.dev/release"
+)
+
+// the kinds checked for objects left over by the previous release, in the
+// target namespace unless cluster scoped
+var installedKinds = []struct {
+	gvk           schema.GroupVersionKind
+	clusterScoped bool
+}{
+	{gvk: appsv1.SchemeGroupVersion.WithKind("Deployment")},
+	{gvk: corev1.SchemeGroupVersion.WithKind("Service")},
+	{gvk: corev1.SchemeGroupVersion.WithKind("ServiceAccount")},
+	{gvk: corev1.SchemeGroupVersion.WithKind("ConfigMap")},
+	{gvk: corev1.SchemeGroupVersion.WithKind("Secret")},
+	{gvk: schema.GroupVersionKind{Group: "tekton.dev", Version: "v1beta1", Kind: "ClusterTask"}, clusterScoped: true},
+}
+
+func TestUpgrade(t *testing.T) {
+	env := integration.Start(t)
+	defer env.Stop(t)
+
+	defer restoreFlags(flag.ResourceDir, flag.TektonVersion, flag.CommunityResourceURLs)
+	flag.CommunityResourceURLs = []string{filepath.Join("test", "integration", "testdata", "community", "echo.yaml")}
+
+	env.CreateNamespace(t, "openshift", nil)
+	env.CreateNamespace(t, flag.TargetNamespace, nil)
+	env.SimulateCluster(flag.TargetNamespace)
+
+	// the previous operator auto installs its payload
+	flag.ResourceDir = filepath.Join("test", "upgrade", "testdata", "previous")
+	flag.TektonVersion = previousVersion
+	startOperator(t, env)
+	cfg := waitForInstall(t, env, previousVersion)
+	assertVersions(t, cfg, previousVersion, previousPipelineRelease, previousTriggersRelease)
+
+	controller := &appsv1.Deployment{}
+	controllerKey := types.NamespacedName{Namespace: flag.TargetNamespace, Name: flag.PipelineControllerName}
+	get(t, env, controllerKey, controller)
+
+	// the defaults edited by the user are kept, the operator only manages
+	// the keys it ships
+	defaults := &corev1.ConfigMap{}
+	defaultsKey := types.NamespacedName{Namespace: flag.TargetNamespace, Name: "config-defaults"}
+	get(t, env, defaultsKey, defaults)
+	defaults.Data["default-timeout-minutes"] = "120"
+	defaults.Labels["team"] = "ci"
+	if err := env.Client.Update(context.TODO(), defaults); err != nil {
+		t.Fatalf("failed to edit config-defaults: %v", err)
+	}
+
+	// the new operator upgrades the installed payload
+	env.RestartManager(t)
+	flag.ResourceDir = filepath.Join("deploy", "resources")
+	flag.TektonVersion = currentVersion
+	startOperator(t, env)
+	cfg = waitForInstall(t, env, currentVersion)
+	assertVersions(t, cfg, currentVersion, currentPipelineRelease, currentTriggersRelease)
+
+	// the selector of the controller is the same in both releases, so it is
+	// updated in place
+	upgraded := &appsv1.Deployment{}
+	get(t, env, controllerKey, upgraded)
+	if upgraded.UID != controller.UID {
+		t.Errorf("expected deployment %s to be updated in place", flag.PipelineControllerName)
+	}
+	if got := upgraded.Spec.Template.Labels[pipelineReleaseLabel]; got != currentPipelineRelease {
+		t.Errorf("expected the pods of %s of release %s, got %q", flag.PipelineControllerName, currentPipelineRelease, got)
+	}
+
+	defaults = &corev1.ConfigMap{}
+	get(t, env, defaultsKey, defaults)
+	if defaults.Data["default-timeout-minutes"] != "120" || defaults.Labels["team"] != "ci" {
+		t.Errorf("expected the user edits of config-defaults to be kept, got data %v and labels %v", defaults.Data, defaults.Labels)
+	}
+	if got := defaults.Labels[pipelineReleaseLabel]; got != currentPipelineRelease {
+		t.Errorf("expected config-defaults of release %s, got %q", currentPipelineRelease, got)
+	}
+
+	assertNoOrphans(t, env, shipped(t,
+		filepath.Join("deploy", "resources", "pipelines", currentPipelineRelease),
+		filepath.Join("deploy", "resources", "triggers", currentTriggersRelease),
+		filepath.Join("deploy", "resources", "addons"),
+		filepath.Join("test", "integration", "testdata", "community"),
+	))
+}
+
+// startOperator adds the config controller to the manager and starts it, as
+// the operator does with the current flags
+func startOperator(t *testing.T, env *integration.Env) {
+	t.Helper()
+	if err := config.Add(env.Manager); err != nil {
+		t.Fatalf("failed to add the config controller: %v", err)
+	}
+	env.StartManager(t)
+}
+
+// waitForInstall waits for the auto created Config to be installed by the
+// operator version
+func waitForInstall(t *testing.T, env *integration.Env, version string) *op.Config {
+	t.Helper()
+
+	cfg := &op.Config{}
+	env.Eventually(t, "install by operator "+version, func() (bool, error) {
+		cfg = &op.Config{}
+		if err := env.Client.Get(context.TODO(), types.NamespacedName{Name: flag.ClusterCRName}, cfg); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		if cfg.InstallStatus() == op.FailedStatus {
+			return false, fmt.Errorf("install failed: %s", cfg.Status.Conditions[0].Details)
+		}
+		return cfg.HasInstalledVersion(version), nil
+	})
+	return cfg
+}
+
+func assertVersions(t *testing.T, cfg *op.Config, version, pipeline, triggers string) {
+	t.Helper()
+
+	installed := cfg.Status.Conditions[0]
+	if installed.Code != op.InstalledStatus || installed.Version != version {
+		t.Errorf("expected %s installed by operator %s, got %s by %s", cfg.Name, version, installed.Code, installed.Version)
+	}
+	if installed.PipelineVersion != pipeline || cfg.Status.DesiredPipelineVersion != pipeline {
+		t.Errorf("expected pipeline %s, got %s installed and %s desired", pipeline, installed.PipelineVersion, cfg.Status.DesiredPipelineVersion)
+	}
+	if installed.TriggersVersion != triggers || cfg.Status.DesiredTriggersVersion != triggers {
+		t.Errorf("expected triggers %s, got %s installed and %s desired", triggers, installed.TriggersVersion, cfg.Status.DesiredTriggersVersion)
+	}
+}
+
+// assertNoOrphans fails when an object installed for the Config is not in
+// expected or carries the release label of the previous release
+func assertNoOrphans(t *testing.T, env *integration.Env, expected map[string]bool) {
+	t.Helper()
+
+	for _, k := range installedKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(k.gvk.GroupVersion().WithKind(k.gvk.Kind + "List"))
+		opts := []client.ListOption{client.MatchingLabels{flag.LabelInstance: flag.ClusterCRName}}
+		if !k.clusterScoped {
+			opts = append(opts, client.InNamespace(flag.TargetNamespace))
+		}
+		if err := env.Client.List(context.TODO(), list, opts...); err != nil {
+			t.Fatalf("failed to list %s: %v", k.gvk.Kind, err)
+		}
+
+		for _, u := range list.Items {
+			name := u.GetKind() + "/" + u.GetName()
+			if !expected[name] && !(u.GetKind() == "ConfigMap" && u.GetName() == flag.TrustedCAConfigMap) {
+				t.Errorf("%s is not part of the current release", name)
+			}
+			labels := u.GetLabels()
+			if v, found := labels[pipelineReleaseLabel]; found && v != currentPipelineRelease {
+				t.Errorf("%s is left from pipeline release %s", name, v)
+			}
+			if v, found := labels[triggersReleaseLabel]; found && v != currentTriggersRelease {
+				t.Errorf("%s is left from triggers release %s", name, v)
+			}
+		}
+	}
+}
+
+// shipped returns the kind/name of every object in the manifests of dirs
+func shipped(t *testing.T, dirs ...string) map[string]bool {
+	t.Helper()
+
+	names := map[string]bool{}
+	for _, dir := range dirs {
+		m, err := mf.ManifestFrom(mf.Recursive(dir))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", dir, err)
+		}
+		for _, u := range m.Resources() {
+			kind := u.GetKind()
+			if kind == "Task" {
+				// catalog tasks are installed as cluster tasks
+				kind = "ClusterTask"
+			}
+			names[kind+"/"+u.GetName()] = true
+		}
+	}
+	return names
+}
+
+func get(t *testing.T, env *integration.Env, key types.NamespacedName, obj runtime.Object) {
+	t.Helper()
+	if err := env.Client.Get(context.TODO(), key, obj); err != nil {
+		t.Fatalf("failed to get %s: %v", key, err)
+	}
+}
+
+func restoreFlags(resourceDir, version string, urls []string) {
+	flag.ResourceDir = resourceDir
+	flag.TektonVersion = version
+	flag.CommunityResourceURLs = urls
+}